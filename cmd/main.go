@@ -1,6 +1,7 @@
 package main
 
 import (
+	"os"
 	"regexp"
 
 	"github.com/eure/bobo"
@@ -12,69 +13,158 @@ import (
 	"github.com/evalphobia/bobo-experiment/experiment/faceplusplus"
 	"github.com/evalphobia/bobo-experiment/experiment/google"
 	"github.com/evalphobia/bobo-experiment/experiment/langchain"
+	"github.com/evalphobia/bobo-experiment/scheduler"
 )
 
 // Entry Point
 func main() {
-	bobo.Run(bobo.RunOption{
-		Engine: &slack.SlackEngine{},
-		Logger: &log.StdLogger{
-			IsDebug: bobo.IsDebug(),
+	// background tasks wait for bobo.Run to initialize the engine.
+	slackEngine := scheduler.NewReadyEngine(&slack.SlackEngine{})
+	logger := &log.StdLogger{
+		IsDebug: bobo.IsDebug(),
+	}
+	awsCostCommand := aws.AWSCostCommand{
+		SourceType: os.Getenv("AWSCOST_SOURCE"),
+		Services:   nil,
+	}
+	awsCostAnomalyCommand := aws.CostAnomalyCommand{
+		SourceType: os.Getenv("AWSCOST_SOURCE"),
+		Factor:     2,
+		MinAmount:  10,
+	}
+	faceplusplus.SetLogger(logger)
+	roomCommand := &google.RoomCommand{}
+	faceSet := &faceplusplus.FaceSet{}
+	mergeTargets := &faceplusplus.MergeTargets{
+		Defaults: map[string][]string{
+			"obama": {
+				"https://upload.wikimedia.org/wikipedia/commons/8/8d/President_Barack_Obama.jpg",
+				"https://upload.wikimedia.org/wikipedia/commons/c/c6/Official_portrait_of_Barack_Obama-2.jpg",
+				"https://www.obamalibrary.gov/sites/default/files/uploads/portals/the-obamas-potus.jpg",
+			},
+		},
+	}
+	// load merge targets at startup to list them in help.
+	if err := mergeTargets.Init(); err != nil {
+		logger.Errorf("MergeTargets", "%s", err.Error())
+	}
+	meetingReminderCommand := &google.MeetingReminderCommand{
+		Engine: slackEngine,
+		Logger: logger,
+	}
+
+	commandSet := command.NewCommandSet(
+		command.PingCommand,
+		command.ParrotCommand,
+		command.GoodMorningCommand,
+		command.ReactEmojiCommand,
+		command.HelpCommand,
+		awsCostCommand,
+		awsCostAnomalyCommand,
+		aws.CostDiffCommand{
+			SourceType: os.Getenv("AWSCOST_SOURCE"),
+			MinAmount:  1,
 		},
-		CommandSet: command.NewCommandSet(
-			command.PingCommand,
-			command.ParrotCommand,
-			command.GoodMorningCommand,
-			command.ReactEmojiCommand,
-			command.HelpCommand,
-			// aws.CostCommandByCloudWatch{
-			// 	Services: nil,
-			// },
-			aws.CostCommandByCostExplorer{
-				Services: nil,
+		aws.CostForecastCommand{},
+		aws.SQSCommand{
+			Metrics: nil,
+		},
+		&aws.SQSPurgeCommand{
+			UseBlacklist: true,
+			Blacklist: []string{
+				"funky-queue",
 			},
-			aws.SQSCommand{
-				Metrics: nil,
+			UseWhitelist: true,
+			Whitelist: []string{
+				"temp-queue",
 			},
-			&aws.SQSPurgeCommand{
-				UseBlacklist: true,
-				Blacklist: []string{
-					"funky-queue",
-				},
-				UseWhitelist: true,
-				Whitelist: []string{
-					"temp-queue",
-				},
-				WhitelistRegexp: []*regexp.Regexp{
-					regexp.MustCompile("^test-.*"),
-					regexp.MustCompile("^dev-.*"),
-				},
+			WhitelistRegexp: []*regexp.Regexp{
+				regexp.MustCompile("^test-.*"),
+				regexp.MustCompile("^dev-.*"),
 			},
-			aws.DynamoDBCommand{
-				Metrics: nil,
+		},
+		aws.DynamoDBCommand{
+			Metrics: nil,
+		},
+		&faceplusplus.MergeCommand{
+			UseBlacklist: true,
+			Blacklist: []string{
+				"evalphobia",
 			},
-			&faceplusplus.MergeCommand{
-				UseBlacklist: true,
-				Blacklist: []string{
-					"evalphobia",
-				},
-				UseWhitelist: false,
-				Whitelist:    nil,
+			UseWhitelist: false,
+			Whitelist:    nil,
+		},
+		&faceplusplus.MergeTargetsCommand{
+			Targets: mergeTargets,
+		},
+		faceplusplus.MergeTargetAddCommand{
+			Targets:      mergeTargets,
+			UseWhitelist: true,
+			Whitelist: []string{
+				"evalphobia",
 			},
-			&faceplusplus.MergeTargetCommand{
-				TargetName: "obama",
-				TargetURLs: []string{
-					"https://upload.wikimedia.org/wikipedia/commons/8/8d/President_Barack_Obama.jpg",
-					"https://upload.wikimedia.org/wikipedia/commons/c/c6/Official_portrait_of_Barack_Obama-2.jpg",
-					"https://www.obamalibrary.gov/sites/default/files/uploads/portals/the-obamas-potus.jpg",
-				},
+		},
+		&faceplusplus.MergeFaceCommand{},
+		&faceplusplus.FaceCommand{},
+		faceplusplus.FaceRegisterCommand{FaceSet: faceSet},
+		faceplusplus.FaceWhoCommand{FaceSet: faceSet},
+		faceplusplus.FaceForgetCommand{FaceSet: faceSet},
+		faceplusplus.FaceppUsageCommand{},
+		&google.GoogleAuthCommand{},
+		google.CalendarCommand,
+		&google.CalendarAddCommand{},
+		google.WhereCommand,
+		roomCommand,
+		google.RoomRefreshCommand{Room: roomCommand},
+		meetingReminderCommand,
+		&google.MeetCommand{
+			IncludeRoom: true,
+			Room:        roomCommand,
+		},
+		&langchain.OpenAIGPTCommand{
+			Command: "gpt",
+		},
+	)
+
+	// run commands periodically.
+	sch := &scheduler.Scheduler{
+		Engine:     slackEngine,
+		CommandSet: commandSet,
+		Logger:     logger,
+		Timezone:   os.Getenv("BOBO_SCHEDULER_TIMEZONE"),
+		Jobs: []scheduler.Job{
+			{
+				Name:         "daily-awscost",
+				Spec:         "0 10 * * *",
+				Command:      awsCostCommand,
+				Channel:      os.Getenv("AWSCOST_REPORT_CHANNEL"),
+				SkipWeekends: true,
 			},
-			google.CalendarCommand,
-			google.WhereCommand,
-			&google.RoomCommand{},
-			&langchain.OpenAIGPTCommand{
-				Command: "gpt",
+			{
+				// costs of the previous day are not fixed until the morning.
+				Name:    "daily-awscost-anomaly",
+				Spec:    "0 9 * * *",
+				Command: awsCostAnomalyCommand,
+				Text:    "quiet",
+				Channel: os.Getenv("AWSCOST_ANOMALY_CHANNEL"),
 			},
-		),
+		},
+	}
+	go func() {
+		if err := sch.Run(); err != nil {
+			logger.Errorf("Scheduler", "%s", err.Error())
+		}
+	}()
+	// send reminders of meetings in background.
+	go func() {
+		if err := meetingReminderCommand.Run(); err != nil {
+			logger.Errorf("MeetingReminder", "%s", err.Error())
+		}
+	}()
+
+	bobo.Run(bobo.RunOption{
+		Engine:     slackEngine,
+		Logger:     logger,
+		CommandSet: commandSet,
 	})
 }
@@ -24,12 +24,22 @@ func getChartURL(url string) string {
 	return defaultChartURL
 }
 
+// chart types.
+const (
+	chartTypeLine    = "line"
+	chartTypeStacked = "stacked"
+)
+
 func createChartURL(endpoint, title string, list Datapoints) (string, error) {
+	return createChartURLWithType(endpoint, title, chartTypeLine, list)
+}
+
+func createChartURLWithType(endpoint, title, chartType string, list Datapoints) (string, error) {
 	params := make(map[string]interface{})
 	params["title"] = title
 	params["label_x"] = "time"
 	params["label_y"] = "value"
-	params["type"] = "line"
+	params["type"] = chartType
 
 	data := make(map[string]map[string]interface{})
 	for _, dp := range list {
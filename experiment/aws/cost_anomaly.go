@@ -0,0 +1,168 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+const (
+	defaultCostAnomalyFactor     = 2.0  // x2 of baseline
+	defaultCostAnomalyMinAmount  = 10.0 // $10
+	defaultCostAnomalyWindow     = 14   // days
+	defaultCostAnomalyMinHistory = 3    // days
+	defaultCostAnomalyTopN       = 3
+)
+
+// costBaselineState is persisted daily costs of each service.
+type costBaselineState struct {
+	Services map[string][]costBaselineEntry `json:"services"`
+}
+
+type costBaselineEntry struct {
+	Date   string  `json:"date"`
+	Amount float64 `json:"amount"`
+}
+
+// Baseline returns an average of daily costs before the date.
+// It returns false when the history is shorter than minHistory.
+func (s costBaselineState) Baseline(service, date string, minHistory int) (float64, bool) {
+	sum := 0.0
+	count := 0
+	for _, e := range s.Services[service] {
+		if e.Date >= date {
+			continue
+		}
+		sum += e.Amount
+		count++
+	}
+	if count == 0 || count < minHistory {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
+
+// Record adds daily costs of the date and drops entries out of the window.
+// Services missing in the report are recorded as zero.
+func (s *costBaselineState) Record(report costReport, window int) {
+	if s.Services == nil {
+		s.Services = make(map[string][]costBaselineEntry)
+	}
+
+	date := report.Period.Start.Format(dateFormat)
+	costs := make(map[string]float64, len(report.Items))
+	for _, item := range report.Items {
+		costs[item.Key] = item.Amount
+	}
+	for service := range s.Services {
+		if _, ok := costs[service]; !ok {
+			costs[service] = 0
+		}
+	}
+
+	minDate := report.Period.Start.AddDate(0, 0, -window).Format(dateFormat)
+	for service, amount := range costs {
+		list := make([]costBaselineEntry, 0, window+1)
+		for _, e := range s.Services[service] {
+			if e.Date == date || e.Date < minDate {
+				continue
+			}
+			list = append(list, e)
+		}
+		list = append(list, costBaselineEntry{
+			Date:   date,
+			Amount: amount,
+		})
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Date < list[j].Date
+		})
+		s.Services[service] = list
+	}
+}
+
+// costAnomaly is a service which exceeds its baseline.
+type costAnomaly struct {
+	Service  string
+	Amount   float64
+	Baseline float64
+	Drivers  costDiffs // increases of usage types
+}
+
+// setDrivers sets usage types which increased from the previous day.
+func (a *costAnomaly) setDrivers(current, previous costReport) {
+	diff := newCostDiffReport(current, previous, 0)
+	for _, d := range diff.Diffs {
+		if d.Change() > 0 {
+			a.Drivers = append(a.Drivers, d)
+		}
+	}
+}
+
+func (a costAnomaly) Factor() float64 {
+	if a.Baseline == 0 {
+		return 0
+	}
+	return a.Amount / a.Baseline
+}
+
+// findCostAnomalies returns services whose costs exceed the baseline by the factor.
+func findCostAnomalies(state costBaselineState, report costReport, factor, minAmount float64, minHistory int) []costAnomaly {
+	date := report.Period.Start.Format(dateFormat)
+
+	var list []costAnomaly
+	for _, item := range report.Items {
+		baseline, ok := state.Baseline(item.Key, date, minHistory)
+		if !ok {
+			continue
+		}
+		if item.Amount-baseline < minAmount || item.Amount < baseline*factor {
+			continue
+		}
+		list = append(list, costAnomaly{
+			Service:  item.Key,
+			Amount:   item.Amount,
+			Baseline: baseline,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Amount-list[i].Baseline > list[j].Amount-list[j].Baseline
+	})
+	return list
+}
+
+type costAnomalyReport struct {
+	Period    costPeriod
+	Unit      string
+	Anomalies []costAnomaly
+}
+
+func (r costAnomalyReport) HasAnomaly() bool {
+	return len(r.Anomalies) != 0
+}
+
+func (r costAnomalyReport) FormatAsOutputReport(topN int) string {
+	results := make([]string, 0, len(r.Anomalies)*(topN+1)+3)
+	results = append(results, i18n.Message("[AWS Cost Anomaly] %s", r.Period.String()))
+	results = append(results, "------------------------")
+	if !r.HasAnomaly() {
+		results = append(results, i18n.Message("No cost anomalies"))
+		return strings.Join(results, "\n")
+	}
+
+	for _, a := range r.Anomalies {
+		factor := i18n.Message("new")
+		if a.Baseline != 0 {
+			factor = fmt.Sprintf("x%.1f", a.Factor())
+		}
+		results = append(results, i18n.Message("- %s:\t%s\t(baseline %s, %s)", a.Service, formatCost(a.Amount, r.Unit), formatCost(a.Baseline, r.Unit), factor))
+		for i, d := range a.Drivers {
+			if i >= topN {
+				break
+			}
+			results = append(results, "    - "+formatCostDiff(d, r.Unit))
+		}
+	}
+	return strings.Join(results, "\n")
+}
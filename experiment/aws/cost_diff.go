@@ -0,0 +1,194 @@
+package aws
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+const defaultCostDiffMinAmount = 1.0 // $1
+
+// costDiffOption is parsed arguments of the diff command.
+// e.g.) "wow 2024-05-31 min:5 top:5"
+type costDiffOption struct {
+	Mode      string // dod or wow
+	Current   costPeriod
+	Previous  costPeriod
+	MinAmount float64
+	HasMin    bool // min: is given, even if it's zero.
+	TopN      int
+}
+
+func parseCostDiffOption(text string, now time.Time) (costDiffOption, error) {
+	opt := costDiffOption{
+		Mode: "dod",
+		TopN: defaultCostTopN,
+	}
+
+	dateText := ""
+	for _, w := range strings.Fields(text) {
+		switch {
+		case w == "dod", w == "wow":
+			opt.Mode = w
+		case strings.HasPrefix(w, "min:"):
+			v, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimPrefix(w, "min:"), "$"), 64)
+			if err != nil || v < 0 {
+				return opt, fmt.Errorf("invalid min: [%s]", w)
+			}
+			opt.MinAmount = v
+			opt.HasMin = true
+		case strings.HasPrefix(w, "top:"):
+			n, err := strconv.Atoi(strings.TrimPrefix(w, "top:"))
+			if err != nil || n < 1 {
+				return opt, fmt.Errorf("invalid top: [%s]", w)
+			}
+			opt.TopN = n
+		default:
+			dateText = w
+		}
+	}
+
+	// the last date of the current period.
+	day, err := parseCostPeriod(dateText, now)
+	if err != nil {
+		return opt, err
+	}
+	if day.Days() != 1 {
+		return opt, fmt.Errorf("set a single date: [%s]", dateText)
+	}
+
+	days := 1
+	if opt.Mode == "wow" {
+		days = 7
+	}
+	opt.Current = newCostPeriod(day.End.AddDate(0, 0, -days), day.End)
+	opt.Previous = newCostPeriod(opt.Current.Start.AddDate(0, 0, -days), opt.Current.Start)
+	return opt, nil
+}
+
+// costDiff is a cost change of a single group between two periods.
+type costDiff struct {
+	Key      string
+	Current  float64
+	Previous float64
+}
+
+func (d costDiff) Change() float64 {
+	return d.Current - d.Previous
+}
+
+// Rate returns relative change in percentage.
+// It returns false when the previous cost is zero.
+func (d costDiff) Rate() (float64, bool) {
+	if d.Previous == 0 {
+		return 0, false
+	}
+	return d.Change() / d.Previous * 100, true
+}
+
+func (d costDiff) isNoise(minAmount float64) bool {
+	switch {
+	case d.Current < minAmount && d.Previous < minAmount,
+		math.Abs(d.Change()) < minAmount:
+		return true
+	}
+	return false
+}
+
+type costDiffs []costDiff
+
+func (l costDiffs) Len() int {
+	return len(l)
+}
+
+func (l costDiffs) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+func (l costDiffs) Less(i, j int) bool {
+	ci, cj := math.Abs(l[i].Change()), math.Abs(l[j].Change())
+	if ci == cj {
+		return l[i].Key < l[j].Key
+	}
+	return ci > cj
+}
+
+// costDiffReport contains cost changes between two reports.
+type costDiffReport struct {
+	Current  costReport
+	Previous costReport
+	Total    costDiff
+	Diffs    costDiffs
+}
+
+// newCostDiffReport compares two reports and drops changes under minAmount as noise.
+func newCostDiffReport(current, previous costReport, minAmount float64) costDiffReport {
+	r := costDiffReport{
+		Current:  current,
+		Previous: previous,
+		Total: costDiff{
+			Key:      "Total",
+			Current:  current.Total,
+			Previous: previous.Total,
+		},
+	}
+
+	diffMap := make(map[string]*costDiff)
+	for _, item := range current.Items {
+		diffMap[item.Key] = &costDiff{Key: item.Key, Current: item.Amount}
+	}
+	for _, item := range previous.Items {
+		d, ok := diffMap[item.Key]
+		if !ok {
+			d = &costDiff{Key: item.Key}
+			diffMap[item.Key] = d
+		}
+		d.Previous = item.Amount
+	}
+
+	for _, d := range diffMap {
+		if d.isNoise(minAmount) {
+			continue
+		}
+		r.Diffs = append(r.Diffs, *d)
+	}
+	sort.Sort(r.Diffs)
+	return r
+}
+
+func (r costDiffReport) FormatAsOutputReport(topN int) string {
+	unit := r.Current.Unit
+	results := make([]string, 0, topN+4)
+	results = append(results, i18n.Message("[AWS Cost Changes] %s vs %s", r.Current.Period.String(), r.Previous.Period.String()))
+	results = append(results, "- "+formatCostDiff(r.Total, unit))
+	results = append(results, "------------------------")
+	if len(r.Diffs) == 0 {
+		results = append(results, i18n.Message("No significant changes"))
+	}
+	for i, d := range r.Diffs {
+		if topN > 0 && i >= topN {
+			break
+		}
+		results = append(results, "- "+formatCostDiff(d, unit))
+	}
+	return strings.Join(results, "\n")
+}
+
+func formatCostDiff(d costDiff, unit string) string {
+	sign := "+"
+	if d.Change() < 0 {
+		sign = "-"
+	}
+	change := sign + formatCost(math.Abs(d.Change()), unit)
+
+	rate := i18n.Message("new")
+	if v, ok := d.Rate(); ok {
+		rate = fmt.Sprintf("%+.1f%%", v)
+	}
+	return fmt.Sprintf("%s:\t%s -> %s\t(%s, %s)", d.Key, formatCost(d.Previous, unit), formatCost(d.Current, unit), change, rate)
+}
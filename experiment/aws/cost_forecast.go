@@ -0,0 +1,110 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+// CostBudget is a monthly budget for an account or a service.
+// Empty Account and Service means the whole cost.
+type CostBudget struct {
+	Name    string  `json:"name"`
+	Account string  `json:"account"` // linked account ID
+	Service string  `json:"service"` // service name of Cost Explorer. (e.g. "Amazon Elastic Compute Cloud - Compute")
+	Amount  float64 `json:"amount"`  // monthly budget
+}
+
+func (b CostBudget) getName() string {
+	switch {
+	case b.Name != "":
+		return b.Name
+	case b.Account != "" && b.Service != "":
+		return b.Account + " / " + b.Service
+	case b.Account != "":
+		return b.Account
+	case b.Service != "":
+		return b.Service
+	}
+	return "Total"
+}
+
+// costForecast is projected month-end spend of a budget.
+type costForecast struct {
+	Budget CostBudget
+	Unit   string
+
+	ElapsedDays int
+	MonthDays   int
+	Actual      float64 // month-to-date
+	Forecast    float64 // remaining days
+}
+
+// newCostForecastPeriods returns month-to-date and the rest of the month.
+// Actual period is empty on the first day of the month.
+func newCostForecastPeriods(now time.Time) (actual, forecast costPeriod) {
+	today := truncateDate(now)
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	nextMonth := monthStart.AddDate(0, 1, 0)
+	return newCostPeriod(monthStart, today), newCostPeriod(today, nextMonth)
+}
+
+func (f costForecast) Projected() float64 {
+	return f.Actual + f.Forecast
+}
+
+// BurnRate returns average daily cost in this month.
+func (f costForecast) BurnRate() float64 {
+	if f.ElapsedDays == 0 {
+		return 0
+	}
+	return f.Actual / float64(f.ElapsedDays)
+}
+
+func (f costForecast) HasBudget() bool {
+	return f.Budget.Amount > 0
+}
+
+func (f costForecast) IsOverBudget() bool {
+	return f.HasBudget() && f.Projected() > f.Budget.Amount
+}
+
+func (f costForecast) budgetPercentage(amount float64) float64 {
+	if !f.HasBudget() {
+		return 0
+	}
+	return amount / f.Budget.Amount * 100
+}
+
+func (f costForecast) format() []string {
+	results := make([]string, 0, 6)
+	title := fmt.Sprintf("[%s]", f.Budget.getName())
+	if f.IsOverBudget() {
+		title += " " + i18n.Message(":warning: Projected spend exceeds the budget!")
+	}
+	results = append(results, title)
+
+	if !f.HasBudget() {
+		results = append(results, i18n.Message("  Actual (MTD):\t%s", formatCost(f.Actual, f.Unit)))
+		results = append(results, i18n.Message("  Projected:\t%s", formatCost(f.Projected(), f.Unit)))
+	} else {
+		results = append(results, i18n.Message("  Actual (MTD):\t%s\t(%.1f%% of budget)", formatCost(f.Actual, f.Unit), f.budgetPercentage(f.Actual)))
+		results = append(results, i18n.Message("  Projected:\t%s\t(%.1f%% of budget)", formatCost(f.Projected(), f.Unit), f.budgetPercentage(f.Projected())))
+		results = append(results, i18n.Message("  Budget:\t%s", formatCost(f.Budget.Amount, f.Unit)))
+	}
+	results = append(results, i18n.Message("  Burn rate:\t%s/day (%d/%d days)", formatCost(f.BurnRate(), f.Unit), f.ElapsedDays, f.MonthDays))
+	return results
+}
+
+type costForecasts []costForecast
+
+func (l costForecasts) FormatAsOutputReport(now time.Time) string {
+	results := make([]string, 0, len(l)*6+1)
+	results = append(results, i18n.Message("[AWS Cost Forecast] %s (as of %s)", now.Format("2006-01"), now.Format(dateFormat)))
+	for _, f := range l {
+		results = append(results, f.format()...)
+	}
+	return strings.Join(results, "\n")
+}
@@ -0,0 +1,93 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FormatAsCSV returns all of the items in CSV format.
+func (r costReport) FormatAsCSV() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	rows := [][]string{
+		{r.GroupBy.String(), "amount", "unit", "percentage"},
+	}
+	for _, item := range r.Items {
+		rows = append(rows, []string{
+			item.Key,
+			fmt.Sprintf("%.2f", item.Amount),
+			r.getUnit(),
+			fmt.Sprintf("%.1f", r.Percentage(item.Amount)),
+		})
+	}
+	rows = append(rows, []string{"Total", fmt.Sprintf("%.2f", r.Total), r.getUnit(), "100.0"})
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FormatAsMarkdown returns all of the items as a Markdown table.
+func (r costReport) FormatAsMarkdown() []byte {
+	results := make([]string, 0, len(r.Items)+6)
+	results = append(results, fmt.Sprintf("# AWS Costs %s", r.Period.String()))
+	results = append(results, "")
+	results = append(results, fmt.Sprintf("| %s | Amount (%s) | %% |", escapeMarkdownCell(r.GroupBy.String()), r.getUnit()))
+	results = append(results, "|---|---:|---:|")
+	for _, item := range r.Items {
+		results = append(results, fmt.Sprintf("| %s | %.2f | %.1f |", escapeMarkdownCell(item.Key), item.Amount, r.Percentage(item.Amount)))
+	}
+	results = append(results, fmt.Sprintf("| **Total** | **%.2f** | 100.0 |", r.Total))
+	return []byte(strings.Join(results, "\n") + "\n")
+}
+
+// GetFileName returns a file name for the uploaded report.
+func (r costReport) GetFileName(ext string) string {
+	return fmt.Sprintf("awscost_%s_%s.%s", r.Period.Start.Format(dateFormat), r.Period.LastDate().Format(dateFormat), ext)
+}
+
+// DailyDatapoints returns daily costs of top-N groups for stacked chart.
+// Groups out of top-N are summed up as "(Other)".
+func (r costReport) DailyDatapoints(topN int) Datapoints {
+	top := make(map[string]struct{}, topN)
+	for i, item := range r.Items {
+		if topN > 0 && i >= topN {
+			break
+		}
+		top[item.Key] = struct{}{}
+	}
+
+	const otherKey = "(Other)"
+	others := make(map[time.Time]float64)
+	list := make(Datapoints, 0, len(r.Daily))
+	for _, dp := range r.Daily {
+		if _, ok := top[dp.MetricName]; ok {
+			list = append(list, dp)
+			continue
+		}
+		others[dp.Time] += dp.Value
+	}
+	for dt, v := range others {
+		list = append(list, Datapoint{
+			MetricName: otherKey,
+			Value:      v,
+			Time:       dt,
+		})
+	}
+	return list
+}
+
+func (r costReport) getUnit() string {
+	if r.Unit == "" {
+		return "USD"
+	}
+	return r.Unit
+}
+
+func escapeMarkdownCell(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
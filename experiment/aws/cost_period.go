@@ -0,0 +1,101 @@
+package aws
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+// costPeriod is a date range for cost queries.
+// End is exclusive, same as TimePeriod of Cost Explorer.
+type costPeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+// parseCostPeriod parses text and returns costPeriod.
+// If text is empty, return yesterday.
+//
+// Supported formats:
+//   - 2024-05-01
+//   - 2024-05-01..2024-05-31
+//   - last-7d
+//   - mtd
+func parseCostPeriod(text string, now time.Time) (costPeriod, error) {
+	today := truncateDate(now)
+	text = strings.ToLower(strings.TrimSpace(text))
+
+	switch {
+	case text == "",
+		text == "yesterday":
+		return newCostPeriod(today.AddDate(0, 0, -1), today), nil
+	case text == "today":
+		return newCostPeriod(today, today.AddDate(0, 0, 1)), nil
+	case text == "mtd":
+		start := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if start.Equal(today) {
+			// use the whole last month on the first day of the month.
+			start = start.AddDate(0, -1, 0)
+		}
+		return newCostPeriod(start, today), nil
+	case strings.HasPrefix(text, "last-") && strings.HasSuffix(text, "d"):
+		days, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(text, "last-"), "d"))
+		if err != nil || days < 1 {
+			return costPeriod{}, fmt.Errorf("invalid days: [%s]", text)
+		}
+		return newCostPeriod(today.AddDate(0, 0, -days), today), nil
+	case strings.Contains(text, ".."):
+		parts := strings.SplitN(text, "..", 2)
+		start, err := time.Parse(dateFormat, parts[0])
+		if err != nil {
+			return costPeriod{}, err
+		}
+		end, err := time.Parse(dateFormat, parts[1])
+		if err != nil {
+			return costPeriod{}, err
+		}
+		if end.Before(start) {
+			return costPeriod{}, fmt.Errorf("end date is before start date: [%s]", text)
+		}
+		return newCostPeriod(start, end.AddDate(0, 0, 1)), nil
+	}
+
+	dt, err := time.Parse(dateFormat, text)
+	if err != nil {
+		return costPeriod{}, err
+	}
+	return newCostPeriod(dt, dt.AddDate(0, 0, 1)), nil
+}
+
+func newCostPeriod(start, end time.Time) costPeriod {
+	return costPeriod{
+		Start: truncateDate(start),
+		End:   truncateDate(end),
+	}
+}
+
+// Days returns the number of days in the period.
+func (p costPeriod) Days() int {
+	return int(p.End.Sub(p.Start).Hours() / 24)
+}
+
+// LastDate returns the last date of the period (inclusive).
+func (p costPeriod) LastDate() time.Time {
+	return p.End.AddDate(0, 0, -1)
+}
+
+func (p costPeriod) String() string {
+	if p.Days() <= 1 {
+		return p.Start.Format(dateFormat)
+	}
+	return fmt.Sprintf("%s - %s", p.Start.Format(dateFormat), p.LastDate().Format(dateFormat))
+}
+
+// Return date of 00:00:00 in UTC.
+func truncateDate(dt time.Time) time.Time {
+	dt = dt.In(time.UTC)
+	return time.Date(dt.Year(), dt.Month(), dt.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,234 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+const defaultCostTopN = 10
+
+// costGroupBy is a grouping key of costs.
+type costGroupBy struct {
+	Dimension string // service, account, region, usage_type, resource
+	TagKey    string // cost-allocation tag key
+}
+
+var costGroupByService = costGroupBy{Dimension: "service"}
+
+func parseCostGroupBy(text string) (costGroupBy, error) {
+	switch {
+	case strings.HasPrefix(text, "tag:"):
+		key := strings.TrimPrefix(text, "tag:")
+		if key == "" {
+			return costGroupBy{}, fmt.Errorf("tag key is empty")
+		}
+		return costGroupBy{TagKey: key}, nil
+	}
+
+	switch text {
+	case "service", "svc":
+		return costGroupBy{Dimension: "service"}, nil
+	case "account", "linked-account":
+		return costGroupBy{Dimension: "account"}, nil
+	case "region":
+		return costGroupBy{Dimension: "region"}, nil
+	case "usage-type", "usage":
+		return costGroupBy{Dimension: "usage_type"}, nil
+	case "resource", "resource-id":
+		return costGroupBy{Dimension: "resource"}, nil
+	}
+	return costGroupBy{}, fmt.Errorf("unsupported group: [%s]", text)
+}
+
+func (g costGroupBy) IsTag() bool {
+	return g.TagKey != ""
+}
+
+func (g costGroupBy) String() string {
+	if g.IsTag() {
+		return "tag:" + g.TagKey
+	}
+	return g.Dimension
+}
+
+// output formats of cost commands.
+const (
+	costFormatText     = "text"
+	costFormatCSV      = "csv"
+	costFormatMarkdown = "md"
+	costFormatChart    = "chart"
+)
+
+// costOption is parsed arguments of cost commands.
+// e.g.) "2024-05-01..2024-05-31 by:account top:5 --format csv"
+type costOption struct {
+	Period  costPeriod
+	GroupBy costGroupBy
+	TopN    int
+	Format  string
+}
+
+func parseCostOption(text string, now time.Time) (costOption, error) {
+	opt := costOption{
+		GroupBy: costGroupByService,
+		TopN:    defaultCostTopN,
+		Format:  costFormatText,
+	}
+
+	periodText := ""
+	words := strings.Fields(text)
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		switch {
+		case w == "--format":
+			if i+1 >= len(words) {
+				return opt, fmt.Errorf("missing value for --format")
+			}
+			i++
+			f, err := parseCostFormat(words[i])
+			if err != nil {
+				return opt, err
+			}
+			opt.Format = f
+		case strings.HasPrefix(w, "--format="):
+			f, err := parseCostFormat(strings.TrimPrefix(w, "--format="))
+			if err != nil {
+				return opt, err
+			}
+			opt.Format = f
+		case strings.HasPrefix(w, "by:"):
+			g, err := parseCostGroupBy(strings.TrimPrefix(w, "by:"))
+			if err != nil {
+				return opt, err
+			}
+			opt.GroupBy = g
+		case strings.HasPrefix(w, "top:"):
+			n, err := strconv.Atoi(strings.TrimPrefix(w, "top:"))
+			if err != nil || n < 1 {
+				return opt, fmt.Errorf("invalid top: [%s]", w)
+			}
+			opt.TopN = n
+		default:
+			periodText = w
+		}
+	}
+
+	period, err := parseCostPeriod(periodText, now)
+	if err != nil {
+		return opt, err
+	}
+	opt.Period = period
+	return opt, nil
+}
+
+func parseCostFormat(text string) (string, error) {
+	switch text {
+	case costFormatText, costFormatCSV, costFormatChart:
+		return text, nil
+	case costFormatMarkdown, "markdown":
+		return costFormatMarkdown, nil
+	}
+	return "", fmt.Errorf("unsupported format: [%s]", text)
+}
+
+// costReport contains costs of the period grouped by costGroupBy.
+type costReport struct {
+	Source  string
+	Period  costPeriod
+	GroupBy costGroupBy
+	Unit    string
+	Total   float64
+	Items   costItems
+	// Daily is daily costs of each group.
+	// It's empty when the source does not support daily costs.
+	Daily Datapoints
+}
+
+type costItem struct {
+	Key    string
+	Amount float64
+}
+
+type costItems []costItem
+
+func (l costItems) Len() int {
+	return len(l)
+}
+
+func (l costItems) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+func (l costItems) Less(i, j int) bool {
+	if l[i].Amount == l[j].Amount {
+		return l[i].Key < l[j].Key
+	}
+	return l[i].Amount > l[j].Amount
+}
+
+func newCostReport(period costPeriod, groupBy costGroupBy, unit string, costs map[string]float64) costReport {
+	r := costReport{
+		Period:  period,
+		GroupBy: groupBy,
+		Unit:    unit,
+		Items:   make(costItems, 0, len(costs)),
+	}
+	for key, amount := range costs {
+		r.Items = append(r.Items, costItem{
+			Key:    key,
+			Amount: amount,
+		})
+		r.Total += amount
+	}
+	sort.Sort(r.Items)
+	return r
+}
+
+// Percentage returns the ratio of the amount to the total.
+func (r costReport) Percentage(amount float64) float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return amount / r.Total * 100
+}
+
+// FormatAsOutputReport returns top-N items with percentages.
+// Items out of top-N are summed up as other.
+func (r costReport) FormatAsOutputReport(topN int) string {
+	if topN < 1 {
+		topN = len(r.Items)
+	}
+
+	results := make([]string, 0, topN+5)
+	results = append(results, i18n.Message("[AWS Estimate Costs] %s", r.Period.String()))
+	results = append(results, i18n.Message("Group by: %s / Source: %s", r.GroupBy.String(), r.Source))
+	results = append(results, fmt.Sprintf("- Total:\t%s", formatCost(r.Total, r.Unit)))
+	results = append(results, "------------------------")
+
+	otherCount := 0
+	otherAmount := 0.0
+	for i, item := range r.Items {
+		if i >= topN {
+			otherCount++
+			otherAmount += item.Amount
+			continue
+		}
+		results = append(results, fmt.Sprintf("- %s:\t%s\t(%.1f%%)", item.Key, formatCost(item.Amount, r.Unit), r.Percentage(item.Amount)))
+	}
+	if otherCount != 0 {
+		results = append(results, i18n.Message("- (Other %d items):\t%s\t(%.1f%%)", otherCount, formatCost(otherAmount, r.Unit), r.Percentage(otherAmount)))
+	}
+	return strings.Join(results, "\n")
+}
+
+func formatCost(amount float64, unit string) string {
+	if unit == "" || unit == "USD" {
+		return fmt.Sprintf("$%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, unit)
+}
@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+)
+
+// cost source types.
+const (
+	CostSourceCostExplorer = "costexplorer"
+	CostSourceCloudWatch   = "cloudwatch"
+	CostSourceFile         = "file"
+)
+
+// CostSource is a data source of AWS costs.
+type CostSource interface {
+	// GetName returns name of the source.
+	GetName() string
+	// FetchCosts fetches costs of the period grouped by the query.
+	FetchCosts(q costQuery) (costReport, error)
+}
+
+// costQuery is a common query for all of the cost sources.
+type costQuery struct {
+	Period  costPeriod
+	GroupBy costGroupBy
+	// Services is a filter for services.
+	// Service names depend on the source. (e.g. "AmazonEC2" for CloudWatch)
+	Services []string
+}
+
+// NewCostSource returns CostSource of the type.
+// If typ is empty, envvar AWSCOST_SOURCE is used, and Cost Explorer is used by default.
+func NewCostSource(typ, filePath string) (CostSource, error) {
+	if typ == "" {
+		typ = os.Getenv("AWSCOST_SOURCE")
+	}
+
+	switch typ {
+	case "", CostSourceCostExplorer:
+		return CostExplorerSource{}, nil
+	case CostSourceCloudWatch:
+		return CloudWatchSource{}, nil
+	case CostSourceFile:
+		if filePath == "" {
+			filePath = os.Getenv("AWSCOST_FILE_PATH")
+		}
+		if filePath == "" {
+			return nil, fmt.Errorf("file path is empty for the cost source: [%s]", typ)
+		}
+		return FileSource{Path: filePath}, nil
+	}
+	return nil, fmt.Errorf("unknown cost source: [%s]", typ)
+}
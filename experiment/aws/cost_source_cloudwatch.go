@@ -0,0 +1,85 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/evalphobia/awscost/cloudwatch"
+)
+
+var _ CostSource = CloudWatchSource{}
+
+// each day needs API calls for every service, so long periods are rejected.
+const maxCloudWatchDays = 31
+
+// CloudWatchSource gets costs from billing metrics of CloudWatch.
+// It supports only grouping by service, and the services must be specified.
+type CloudWatchSource struct{}
+
+func (CloudWatchSource) GetName() string {
+	return CostSourceCloudWatch
+}
+
+func (CloudWatchSource) FetchCosts(q costQuery) (costReport, error) {
+	if q.GroupBy != costGroupByService {
+		return costReport{}, fmt.Errorf("[%s] source supports only grouping by service", CostSourceCloudWatch)
+	}
+
+	services := q.Services
+	if len(services) == 0 {
+		services = defaultCloudWatchServices
+	}
+
+	if days := q.Period.Days(); days > maxCloudWatchDays {
+		return costReport{}, fmt.Errorf("[%s] source supports up to %d days: [%d days]", CostSourceCloudWatch, maxCloudWatchDays, days)
+	}
+
+	// billing metrics are cumulative in the month and cloudwatch.Fetch returns the cost of a single day,
+	// so fetch costs day by day and sum them.
+	svcCosts := make(map[string]float64, len(services)+1)
+	for day := q.Period.Start; day.Before(q.Period.End); day = day.AddDate(0, 0, 1) {
+		// use 23:59:59 of the previous day and the day, same as awscost package.
+		costs, err := cloudwatch.Fetch(day.Add(-time.Second), day.AddDate(0, 0, 1).Add(-time.Second), services...)
+		if err != nil {
+			return costReport{}, err
+		}
+
+		for key, val := range costs.Services {
+			svcCosts[key] += val
+		}
+		if costs.Other != 0 {
+			svcCosts["(Other)"] += costs.Other
+		}
+	}
+
+	report := newCostReport(q.Period, q.GroupBy, "USD", svcCosts)
+	report.Source = CostSourceCloudWatch
+	return report, nil
+}
+
+// service names on CloudWatch billing metrics.
+var defaultCloudWatchServices = []string{
+	"AmazonApiGateway",
+	"AmazonCloudWatch",
+	"AmazonEC2",
+	"AmazonECR",
+	"AmazonDynamoDB",
+	"AmazonElastiCache",
+	"AmazonES",
+	"AmazonGuardDuty",
+	"AmazonInspector",
+	"AmazonKinesis",
+	"AmazonKinesisFirehose",
+	"AmazonRDS",
+	"AmazonRekognition",
+	"AmazonRoute53",
+	"AmazonS3",
+	"AmazonSageMaker",
+	"AmazonSES",
+	"AmazonSNS",
+	"AWSDataTransfer",
+	"AWSIoT",
+	"AWSLambda",
+	"AWSQueueService",
+	"CodeBuild",
+}
@@ -0,0 +1,35 @@
+package aws
+
+import (
+	"fmt"
+
+	SDK "github.com/aws/aws-sdk-go/service/costexplorer"
+)
+
+var _ CostSource = CostExplorerSource{}
+
+// CostExplorerSource gets costs from Cost Explorer API.
+// Each API request is billed.
+type CostExplorerSource struct{}
+
+func (CostExplorerSource) GetName() string {
+	return CostSourceCostExplorer
+}
+
+func (CostExplorerSource) FetchCosts(q costQuery) (costReport, error) {
+	if q.GroupBy.Dimension == "resource" {
+		return costReport{}, fmt.Errorf("[%s] source does not support grouping by resource, use CUR file instead", CostSourceCostExplorer)
+	}
+
+	var filter *SDK.Expression
+	if len(q.Services) != 0 {
+		filter = newCostExplorerDimensionExpression(SDK.DimensionService, q.Services...)
+	}
+
+	report, err := fetchCostExplorerCostsWithFilter(q.Period, q.GroupBy, filter)
+	if err != nil {
+		return report, err
+	}
+	report.Source = CostSourceCostExplorer
+	return report, nil
+}
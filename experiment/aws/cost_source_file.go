@@ -0,0 +1,538 @@
+package aws
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/evalphobia/aws-sdk-go-wrapper/config"
+)
+
+var _ CostSource = FileSource{}
+
+// FileSource gets costs from Cost and Usage Report (CUR) files.
+//
+// Path is one of below,
+//   - local file:      /path/to/report.csv.gz
+//   - local directory: /path/to/reports/ (all of CSV files in it and sub directories)
+//   - S3 object:       s3://bucket/path/to/report.csv.gz
+//   - S3 prefix:       s3://bucket/path/to/reports/
+//
+// When a directory or a prefix has manifest files of CUR, only the latest version of each billing period is used.
+// Without manifest files, it fails when a billing period directory has several versions.
+// CSV and gzipped CSV are supported. Parquet is not supported.
+type FileSource struct {
+	Path string
+}
+
+func (FileSource) GetName() string {
+	return CostSourceFile
+}
+
+func (s FileSource) FetchCosts(q costQuery) (costReport, error) {
+	var paths []string
+	var err error
+	var open func(string) (io.ReadCloser, error)
+	switch {
+	case strings.HasPrefix(s.Path, "s3://"):
+		paths, err = listS3CURFiles(s.Path)
+		open = openS3File
+	default:
+		paths, err = listLocalCURFiles(s.Path)
+		open = openLocalFile
+	}
+	if err != nil {
+		return costReport{}, err
+	}
+	if len(paths) == 0 {
+		return costReport{}, fmt.Errorf("CUR file does not exist: [%s]", s.Path)
+	}
+
+	agg := newCURAggregator(q)
+	for _, path := range paths {
+		if err := readCURFile(agg, path, open); err != nil {
+			return costReport{}, fmt.Errorf("[%s] %w", path, err)
+		}
+	}
+
+	report := agg.Report()
+	report.Source = CostSourceFile
+	return report, nil
+}
+
+func readCURFile(agg *curAggregator, path string, open func(string) (io.ReadCloser, error)) error {
+	if isParquetFile(path) {
+		return fmt.Errorf("Parquet format is not supported, use CSV (or gzip) format for CUR")
+	}
+
+	f, err := open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	return agg.Read(r)
+}
+
+func isCURFile(path string) bool {
+	return strings.HasSuffix(path, ".csv") || strings.HasSuffix(path, ".csv.gz") || isParquetFile(path)
+}
+
+func isParquetFile(path string) bool {
+	return strings.HasSuffix(path, ".parquet")
+}
+
+// listLocalCURFiles returns the file itself, or CUR files in the directory and its sub directories.
+func listLocalCURFiles(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	manifests := make(map[string]time.Time) // value=modified time
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case info.IsDir():
+			return nil
+		case isCURManifestFile(p):
+			manifests[p] = info.ModTime()
+		case isCURFile(p):
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return selectCURFiles(files, manifests, func(manifestPath, key string) string {
+		return filepath.Join(filepath.Dir(manifestPath), filepath.FromSlash(getReportKeyFromBillingPeriod(manifestPath, key)))
+	}, func(manifestPath string) ([]byte, error) {
+		return ioutil.ReadFile(manifestPath)
+	})
+}
+
+func openLocalFile(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// aws-sdk-go-wrapper reads whole of the object into memory, so use SDK client directly for large CUR files.
+var s3SDKOnce sync.Once
+var s3SDKCli *s3.S3
+
+func getOrCreateS3SDKClient() (*s3.S3, error) {
+	var err error
+	s3SDKOnce.Do(func() {
+		sess, e := config.Config{}.Session()
+		if e != nil {
+			err = e
+			return
+		}
+		s3SDKCli = s3.New(sess)
+	})
+	return s3SDKCli, err
+}
+
+// parseS3Path parses "s3://bucket/key" into bucket and key.
+func parseS3Path(path string) (bucket, key string) {
+	path = strings.TrimPrefix(path, "s3://")
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// listS3CURFiles returns the object itself, or CUR objects under the prefix when path ends with "/".
+func listS3CURFiles(path string) ([]string, error) {
+	bucket, key := parseS3Path(path)
+	if key != "" && !strings.HasSuffix(key, "/") {
+		return []string{path}, nil
+	}
+
+	cli, err := getOrCreateS3SDKClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	manifests := make(map[string]time.Time) // value=modified time
+	err = cli.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: pointerString(bucket),
+		Prefix: pointerString(key),
+	}, func(out *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range out.Contents {
+			switch {
+			case obj.Key == nil:
+				continue
+			case isCURManifestFile(*obj.Key):
+				var modTime time.Time
+				if obj.LastModified != nil {
+					modTime = *obj.LastModified
+				}
+				manifests[fmt.Sprintf("s3://%s/%s", bucket, *obj.Key)] = modTime
+			case isCURFile(*obj.Key):
+				files = append(files, fmt.Sprintf("s3://%s/%s", bucket, *obj.Key))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// reportKeys of the manifest are object keys in the bucket.
+	return selectCURFiles(files, manifests, func(manifestPath, key string) string {
+		return fmt.Sprintf("s3://%s/%s", bucket, key)
+	}, func(manifestPath string) ([]byte, error) {
+		f, err := openS3File(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ioutil.ReadAll(f)
+	})
+}
+
+// curManifest is a manifest file of CUR, which is saved with each version of the report.
+//
+// Directories of CUR are below,
+//   - <prefix>/<name>/<billing period>/<name>-Manifest.json               (the latest version)
+//   - <prefix>/<name>/<billing period>/<assembly id>/<name>-Manifest.json (each version)
+//   - <prefix>/<name>/<billing period>/<assembly id>/<name>-1.csv.gz
+type curManifest struct {
+	AssemblyID string   `json:"assemblyId"`
+	ReportKeys []string `json:"reportKeys"`
+
+	path    string
+	modTime time.Time
+}
+
+// isLatest checks the manifest is the latest version, which is placed in the billing period directory.
+func (m curManifest) isLatest() bool {
+	return filepath.Base(getSlashDir(m.path)) != m.AssemblyID
+}
+
+// isNewerThan checks the manifest should be used rather than the other one of the same billing period.
+// The manifest in the billing period directory always wins, and then the modified time is compared.
+// (assembly ids are random and not ordered by time)
+func (m curManifest) isNewerThan(other curManifest) bool {
+	switch {
+	case m.isLatest() != other.isLatest():
+		return m.isLatest()
+	case !m.modTime.Equal(other.modTime):
+		return m.modTime.After(other.modTime)
+	}
+	return m.path > other.path
+}
+
+// getBillingPeriodDir returns the billing period directory of the manifest.
+func (m curManifest) getBillingPeriodDir() string {
+	dir := getSlashDir(m.path)
+	if m.isLatest() {
+		return dir
+	}
+	return getSlashDir(dir)
+}
+
+func isCURManifestFile(path string) bool {
+	return strings.HasSuffix(path, "-Manifest.json")
+}
+
+// selectCURFiles returns report files of the latest version in each billing period.
+// CUR keeps old versions in the same prefix, so using all of the files counts costs twice.
+// When there is no manifest, all of the files are used unless a billing period has several versions.
+func selectCURFiles(files []string, manifests map[string]time.Time, resolve func(manifestPath, key string) string, read func(manifestPath string) ([]byte, error)) ([]string, error) {
+	if len(manifests) == 0 {
+		if err := checkCURVersions(files); err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+
+	latest := make(map[string]curManifest) // key=billing period directory
+	for path, modTime := range manifests {
+		byt, err := read(path)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] %w", path, err)
+		}
+		m := curManifest{path: path, modTime: modTime}
+		if err := json.Unmarshal(byt, &m); err != nil {
+			return nil, fmt.Errorf("[%s] %w", path, err)
+		}
+
+		dir := m.getBillingPeriodDir()
+		if prev, ok := latest[dir]; ok && !m.isNewerThan(prev) {
+			continue
+		}
+		latest[dir] = m
+	}
+
+	var paths []string
+	for _, m := range latest {
+		for _, key := range m.ReportKeys {
+			if isCURFile(key) {
+				paths = append(paths, resolve(m.path, key))
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// billing period directory of CUR. (e.g. "20240501-20240601")
+var curBillingPeriodDirRegexp = regexp.MustCompile(`^[0-9]{8}-[0-9]{8}$`)
+
+// checkCURVersions returns an error when a billing period directory has files of several versions.
+// The latest version cannot be decided without manifests.
+func checkCURVersions(files []string) error {
+	versions := make(map[string]map[string]struct{}) // key=billing period directory, value=assembly ids
+	for _, path := range files {
+		parts := strings.Split(filepath.ToSlash(path), "/")
+		for i := 0; i < len(parts)-2; i++ {
+			if !curBillingPeriodDirRegexp.MatchString(parts[i]) {
+				continue
+			}
+			dir := strings.Join(parts[:i+1], "/")
+			if versions[dir] == nil {
+				versions[dir] = make(map[string]struct{})
+			}
+			versions[dir][parts[i+1]] = struct{}{}
+			break
+		}
+	}
+
+	dirs := make([]string, 0, len(versions))
+	for dir := range versions {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if len(versions[dir]) > 1 {
+			return fmt.Errorf("several versions of CUR exist in [%s] without manifest, set the path to a single version or keep manifest files", dir)
+		}
+	}
+	return nil
+}
+
+// getReportKeyFromBillingPeriod returns the path of the report key from the billing period directory of the manifest.
+// e.g.) "prefix/name/20240501-20240601/xxx/name-1.csv.gz" -> "xxx/name-1.csv.gz"
+func getReportKeyFromBillingPeriod(manifestPath, key string) string {
+	dir := filepath.Base(getSlashDir(manifestPath)) + "/"
+	if i := strings.Index(key, dir); i >= 0 {
+		return key[i+len(dir):]
+	}
+	return key
+}
+
+// getSlashDir returns the parent directory of local path or S3 path with slash separators.
+func getSlashDir(path string) string {
+	path = filepath.ToSlash(path)
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func openS3File(path string) (io.ReadCloser, error) {
+	cli, err := getOrCreateS3SDKClient()
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, key := parseS3Path(path)
+	out, err := cli.GetObject(&s3.GetObjectInput{
+		Bucket: pointerString(bucket),
+		Key:    pointerString(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+// column names of CUR.
+const (
+	curColumnUsageStartDate = "lineItem/UsageStartDate"
+	curColumnProductCode    = "lineItem/ProductCode"
+	curColumnUsageAccountID = "lineItem/UsageAccountId"
+	curColumnUsageType      = "lineItem/UsageType"
+	curColumnResourceID     = "lineItem/ResourceId"
+	curColumnUnblendedCost  = "lineItem/UnblendedCost"
+	curColumnCurrencyCode   = "lineItem/CurrencyCode"
+	curColumnRegion         = "product/region"
+	curColumnTagPrefix      = "resourceTags/user:"
+)
+
+// curHeader is a column index map of CUR.
+type curHeader map[string]int
+
+func newCURHeader(row []string) (curHeader, error) {
+	h := make(curHeader, len(row))
+	for i, name := range row {
+		h[strings.TrimSpace(name)] = i
+	}
+
+	for _, name := range []string{curColumnUsageStartDate, curColumnProductCode, curColumnUnblendedCost} {
+		if _, ok := h[name]; !ok {
+			return nil, fmt.Errorf("required column does not exist: [%s]", name)
+		}
+	}
+	return h, nil
+}
+
+// Get returns value of the column.
+func (h curHeader) Get(row []string, name string) string {
+	i, ok := h[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return row[i]
+}
+
+// GroupKey returns grouping value of the row.
+func (h curHeader) GroupKey(row []string, groupBy costGroupBy) string {
+	var key string
+	switch {
+	case groupBy.IsTag():
+		key = h.Get(row, curColumnTagPrefix+groupBy.TagKey)
+		if key == "" {
+			key = "(untagged)"
+		}
+		return key
+	case groupBy.Dimension == "account":
+		key = h.Get(row, curColumnUsageAccountID)
+	case groupBy.Dimension == "region":
+		key = h.Get(row, curColumnRegion)
+	case groupBy.Dimension == "usage_type":
+		key = h.Get(row, curColumnUsageType)
+	case groupBy.Dimension == "resource":
+		key = h.Get(row, curColumnResourceID)
+	default:
+		key = h.Get(row, curColumnProductCode)
+	}
+
+	if key == "" {
+		return "(none)"
+	}
+	return key
+}
+
+// curAggregator aggregates costs of CUR data by the query.
+// CUR is split into multiple files, so Read can be called for each file.
+type curAggregator struct {
+	query    costQuery
+	services map[string]struct{}
+	unit     string
+	costs    map[string]float64
+	daily    map[string]map[time.Time]float64
+}
+
+func newCURAggregator(q costQuery) *curAggregator {
+	services := make(map[string]struct{}, len(q.Services))
+	for _, s := range q.Services {
+		services[s] = struct{}{}
+	}
+	return &curAggregator{
+		query:    q,
+		services: services,
+		costs:    make(map[string]float64),
+		daily:    make(map[string]map[time.Time]float64),
+	}
+}
+
+// Read reads CUR data in CSV format.
+func (a *curAggregator) Read(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	row, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	header, err := newCURHeader(row)
+	if err != nil {
+		return err
+	}
+
+	for {
+		row, err := cr.Read()
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+
+		dt, err := time.Parse(time.RFC3339, header.Get(row, curColumnUsageStartDate))
+		if err != nil {
+			continue
+		}
+		if dt.Before(a.query.Period.Start) || !dt.Before(a.query.Period.End) {
+			continue
+		}
+		if len(a.services) != 0 {
+			if _, ok := a.services[header.Get(row, curColumnProductCode)]; !ok {
+				continue
+			}
+		}
+
+		cost, err := strconv.ParseFloat(header.Get(row, curColumnUnblendedCost), 64)
+		if err != nil {
+			continue
+		}
+		if u := header.Get(row, curColumnCurrencyCode); u != "" {
+			a.unit = u
+		}
+		key := header.GroupKey(row, a.query.GroupBy)
+		a.costs[key] += cost
+		if _, ok := a.daily[key]; !ok {
+			a.daily[key] = make(map[time.Time]float64)
+		}
+		a.daily[key][truncateDate(dt)] += cost
+	}
+}
+
+// Report returns aggregated costs.
+func (a *curAggregator) Report() costReport {
+	report := newCostReport(a.query.Period, a.query.GroupBy, a.unit, a.costs)
+	for key, days := range a.daily {
+		for dt, cost := range days {
+			report.Daily = append(report.Daily, Datapoint{
+				MetricName: key,
+				Value:      cost,
+				Time:       dt,
+			})
+		}
+	}
+	return report
+}
@@ -0,0 +1,240 @@
+package aws
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileSourceFetchCosts(t *testing.T) {
+	period := newCostPeriod(
+		time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC),
+	)
+
+	tests := []struct {
+		name     string
+		groupBy  costGroupBy
+		services []string
+		want     map[string]float64
+	}{
+		{
+			name:    "service",
+			groupBy: costGroupBy{Dimension: "service"},
+			want:    map[string]float64{"AmazonEC2": 14.5, "AmazonS3": 2.25},
+		},
+		{
+			name:    "account",
+			groupBy: costGroupBy{Dimension: "account"},
+			want:    map[string]float64{"111111111111": 12.75, "222222222222": 4},
+		},
+		{
+			name:    "region",
+			groupBy: costGroupBy{Dimension: "region"},
+			want:    map[string]float64{"ap-northeast-1": 10.5, "us-east-1": 6.25},
+		},
+		{
+			name:    "usage_type",
+			groupBy: costGroupBy{Dimension: "usage_type"},
+			want:    map[string]float64{"APN1-BoxUsage:t3.micro": 10.5, "TimedStorage-ByteHrs": 2.25, "BoxUsage:m5.large": 4},
+		},
+		{
+			name:    "resource",
+			groupBy: costGroupBy{Dimension: "resource"},
+			want:    map[string]float64{"i-0001": 10.5, "bucket-a": 2.25, "i-0002": 4},
+		},
+		{
+			name:    "tag",
+			groupBy: costGroupBy{TagKey: "team"},
+			want:    map[string]float64{"web": 10.5, "batch": 4, "(untagged)": 2.25},
+		},
+		{
+			name:     "service filter",
+			groupBy:  costGroupBy{Dimension: "service"},
+			services: []string{"AmazonS3"},
+			want:     map[string]float64{"AmazonS3": 2.25},
+		},
+	}
+
+	for _, file := range []string{"simple.csv", "simple.csv.gz"} {
+		for _, tt := range tests {
+			t.Run(file+"/"+tt.name, func(t *testing.T) {
+				s := FileSource{Path: filepath.Join("testdata", "cur", file)}
+				report, err := s.FetchCosts(costQuery{
+					Period:   period,
+					GroupBy:  tt.groupBy,
+					Services: tt.services,
+				})
+				if err != nil {
+					t.Fatalf("FetchCosts() error = %v", err)
+				}
+				if got := reportToMap(report); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("FetchCosts() = %v, want %v", got, tt.want)
+				}
+				if report.Unit != "USD" {
+					t.Errorf("Unit = %s, want USD", report.Unit)
+				}
+			})
+		}
+	}
+}
+
+func TestFileSourceFetchCostsDaily(t *testing.T) {
+	s := FileSource{Path: filepath.Join("testdata", "cur", "simple.csv")}
+	report, err := s.FetchCosts(costQuery{
+		Period: newCostPeriod(
+			time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC),
+		),
+		GroupBy:  costGroupByService,
+		Services: []string{"AmazonEC2"},
+	})
+	if err != nil {
+		t.Fatalf("FetchCosts() error = %v", err)
+	}
+
+	got := make(map[string]float64)
+	for _, p := range report.Daily {
+		got[p.MetricName+"|"+p.Time.Format(dateFormat)] += p.Value
+	}
+	want := map[string]float64{
+		"AmazonEC2|2024-05-01": 10.5,
+		"AmazonEC2|2024-05-02": 4,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Daily = %v, want %v", got, want)
+	}
+}
+
+func TestFileSourceFetchCostsDirectory(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want map[string]float64
+	}{
+		{
+			// old versions of the report are ignored.
+			name: "latest version by manifest",
+			path: filepath.Join("testdata", "cur_manifest"),
+			want: map[string]float64{"AmazonEC2": 1},
+		},
+		{
+			name: "sub directories without manifest",
+			path: filepath.Join("testdata", "cur_nested"),
+			want: map[string]float64{"AmazonS3": 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := FileSource{Path: tt.path}
+			report, err := s.FetchCosts(costQuery{
+				Period: newCostPeriod(
+					time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+					time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+				),
+				GroupBy: costGroupByService,
+			})
+			if err != nil {
+				t.Fatalf("FetchCosts() error = %v", err)
+			}
+			if got := reportToMap(report); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FetchCosts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectCURFiles(t *testing.T) {
+	const dir = "cur/report/20240501-20240601"
+	manifestOf := func(id string, keys ...string) string {
+		byt, _ := json.Marshal(curManifest{AssemblyID: id, ReportKeys: keys})
+		return string(byt)
+	}
+	newFiles := []string{dir + "/new/report-1.csv.gz", dir + "/new/report-2.csv.gz"}
+	// assembly ids are not ordered by time.
+	oldFiles := []string{dir + "/zzz-old/report-1.csv.gz"}
+	allFiles := append(append([]string{}, newFiles...), oldFiles...)
+	oldTime := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	newTime := time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		files     []string
+		manifests map[string]time.Time
+		contents  map[string]string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:  "top-level manifest",
+			files: allFiles,
+			manifests: map[string]time.Time{
+				dir + "/report-Manifest.json":         newTime,
+				dir + "/new/report-Manifest.json":     newTime,
+				dir + "/zzz-old/report-Manifest.json": oldTime,
+			},
+			contents: map[string]string{
+				dir + "/report-Manifest.json":         manifestOf("new", newFiles...),
+				dir + "/new/report-Manifest.json":     manifestOf("new", newFiles...),
+				dir + "/zzz-old/report-Manifest.json": manifestOf("zzz-old", oldFiles...),
+			},
+			want: newFiles,
+		},
+		{
+			name:  "newest manifest without top-level manifest",
+			files: allFiles,
+			manifests: map[string]time.Time{
+				dir + "/new/report-Manifest.json":     newTime,
+				dir + "/zzz-old/report-Manifest.json": oldTime,
+			},
+			contents: map[string]string{
+				dir + "/new/report-Manifest.json":     manifestOf("new", newFiles...),
+				dir + "/zzz-old/report-Manifest.json": manifestOf("zzz-old", oldFiles...),
+			},
+			want: newFiles,
+		},
+		{
+			name:  "single version without manifest",
+			files: newFiles,
+			want:  newFiles,
+		},
+		{
+			name:    "several versions without manifest",
+			files:   allFiles,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectCURFiles(tt.files, tt.manifests, func(manifestPath, key string) string {
+				return key
+			}, func(manifestPath string) ([]byte, error) {
+				return []byte(tt.contents[manifestPath]), nil
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("selectCURFiles() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectCURFiles() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectCURFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func reportToMap(r costReport) map[string]float64 {
+	m := make(map[string]float64, len(r.Items))
+	for _, item := range r.Items {
+		m[item.Key] = item.Amount
+	}
+	return m
+}
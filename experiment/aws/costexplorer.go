@@ -0,0 +1,211 @@
+package aws
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	SDK "github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/evalphobia/aws-sdk-go-wrapper/config"
+	"github.com/evalphobia/aws-sdk-go-wrapper/costexplorer"
+)
+
+var ceOnce sync.Once
+var ceCli *costexplorer.CostExplorer
+
+func getOrCreateCostExplorerClient() (*costexplorer.CostExplorer, error) {
+	var err error
+	ceOnce.Do(func() {
+		ceCli, err = costexplorer.New(config.Config{})
+	})
+	return ceCli, err
+}
+
+// aws-sdk-go-wrapper does not support forecast API, so use SDK client directly.
+var ceSDKOnce sync.Once
+var ceSDKCli *SDK.CostExplorer
+
+func getOrCreateCostExplorerSDKClient() (*SDK.CostExplorer, error) {
+	var err error
+	ceSDKOnce.Do(func() {
+		sess, e := config.Config{}.Session()
+		if e != nil {
+			err = e
+			return
+		}
+		ceSDKCli = SDK.New(sess)
+	})
+	return ceSDKCli, err
+}
+
+// fetchCostExplorerCosts fetches costs of the period grouped by groupBy.
+func fetchCostExplorerCosts(period costPeriod, groupBy costGroupBy) (costReport, error) {
+	return fetchCostExplorerCostsWithFilter(period, groupBy, nil)
+}
+
+// fetchCostExplorerCostsWithFilter fetches costs of the period grouped by groupBy and narrowed by filter.
+func fetchCostExplorerCostsWithFilter(period costPeriod, groupBy costGroupBy, filter *SDK.Expression) (costReport, error) {
+	cli, err := getOrCreateCostExplorerClient()
+	if err != nil {
+		return costReport{}, err
+	}
+
+	unit := ""
+	costs := make(map[string]float64)
+	var daily Datapoints
+	nextPageToken := ""
+	for {
+		input := costexplorer.GetCostAndUsageInput{
+			NextPageToken:    nextPageToken,
+			TimePeriodStart:  period.Start,
+			TimePeriodEnd:    period.End,
+			GranularityDaily: true,
+		}.ToInput()
+		input.GroupBy = []*SDK.GroupDefinition{newCostExplorerGroupDefinition(groupBy)}
+		input.Filter = filter
+
+		resp, err := cli.DoGetCostAndUsage(input)
+		if err != nil {
+			return costReport{}, err
+		}
+
+		for _, r := range resp.ResultsByTime {
+			dt, _ := time.Parse(dateFormat, r.TimePeriodStart)
+			for _, g := range r.Groups {
+				if len(g.Keys) == 0 {
+					continue
+				}
+				amount, u := g.GetOne()
+				cost, err := strconv.ParseFloat(amount, 64)
+				if err != nil {
+					continue
+				}
+				if u != "" {
+					unit = u
+				}
+				key := getCostExplorerGroupKey(groupBy, g.Keys[0])
+				costs[key] += cost
+				daily = append(daily, Datapoint{
+					MetricName: key,
+					Value:      cost,
+					Time:       dt,
+				})
+			}
+		}
+
+		if resp.NextPageToken == "" {
+			break
+		}
+		nextPageToken = resp.NextPageToken
+	}
+	report := newCostReport(period, groupBy, unit, costs)
+	report.Daily = daily
+	return report, nil
+}
+
+// fetchCostExplorerForecast fetches forecasted cost of the period.
+func fetchCostExplorerForecast(period costPeriod, filter *SDK.Expression) (amount float64, unit string, err error) {
+	cli, err := getOrCreateCostExplorerSDKClient()
+	if err != nil {
+		return 0, "", err
+	}
+
+	resp, err := cli.GetCostForecast(&SDK.GetCostForecastInput{
+		TimePeriod: &SDK.DateInterval{
+			Start: pointerString(period.Start.Format(dateFormat)),
+			End:   pointerString(period.End.Format(dateFormat)),
+		},
+		Granularity: pointerString(SDK.GranularityMonthly),
+		Metric:      pointerString(SDK.MetricUnblendedCost),
+		Filter:      filter,
+	})
+	switch {
+	case err != nil:
+		return 0, "", err
+	case resp.Total == nil,
+		resp.Total.Amount == nil:
+		return 0, "", nil
+	}
+
+	if resp.Total.Unit != nil {
+		unit = *resp.Total.Unit
+	}
+	amount, err = strconv.ParseFloat(*resp.Total.Amount, 64)
+	return amount, unit, err
+}
+
+// newCostExplorerFilter creates filter expression for the linked account and the service.
+// It returns nil when both are empty.
+func newCostExplorerFilter(account, service string) *SDK.Expression {
+	list := make([]*SDK.Expression, 0, 2)
+	if account != "" {
+		list = append(list, newCostExplorerDimensionExpression(SDK.DimensionLinkedAccount, account))
+	}
+	if service != "" {
+		list = append(list, newCostExplorerDimensionExpression(SDK.DimensionService, service))
+	}
+
+	switch len(list) {
+	case 0:
+		return nil
+	case 1:
+		return list[0]
+	}
+	return &SDK.Expression{
+		And: list,
+	}
+}
+
+func newCostExplorerDimensionExpression(key string, values ...string) *SDK.Expression {
+	list := make([]*string, len(values))
+	for i, v := range values {
+		list[i] = pointerString(v)
+	}
+	return &SDK.Expression{
+		Dimensions: &SDK.DimensionValues{
+			Key:    pointerString(key),
+			Values: list,
+		},
+	}
+}
+
+func newCostExplorerGroupDefinition(groupBy costGroupBy) *SDK.GroupDefinition {
+	if groupBy.IsTag() {
+		return &SDK.GroupDefinition{
+			Type: pointerString(SDK.GroupDefinitionTypeTag),
+			Key:  pointerString(groupBy.TagKey),
+		}
+	}
+
+	key := SDK.DimensionService
+	switch groupBy.Dimension {
+	case "account":
+		key = SDK.DimensionLinkedAccount
+	case "region":
+		key = SDK.DimensionRegion
+	case "usage_type":
+		key = SDK.DimensionUsageType
+	}
+	return &SDK.GroupDefinition{
+		Type: pointerString(SDK.GroupDefinitionTypeDimension),
+		Key:  pointerString(key),
+	}
+}
+
+// tag key is returned as "<TagKey>$<TagValue>".
+func getCostExplorerGroupKey(groupBy costGroupBy, key string) string {
+	if !groupBy.IsTag() {
+		return key
+	}
+
+	value := strings.TrimPrefix(key, groupBy.TagKey+"$")
+	if value == "" {
+		return "(untagged)"
+	}
+	return value
+}
+
+func pointerString(s string) *string {
+	return &s
+}
@@ -0,0 +1,159 @@
+package aws
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var _ command.CommandTemplate = AWSCostCommand{}
+
+type AWSCostCommand struct {
+	// SourceType is one of "costexplorer", "cloudwatch" and "file".
+	// If it's empty, envvar AWSCOST_SOURCE is used. (default: costexplorer)
+	SourceType string
+	// FilePath is CUR file path for "file" source.
+	// If it's empty, envvar AWSCOST_FILE_PATH is used.
+	FilePath string
+	// Services is a filter for services.
+	// Service names depend on the source.
+	Services []string
+	// ChartEndpoint is used for "--format chart".
+	// If it's empty, envvar CHART_ANGEL_ENDPOINT is used.
+	ChartEndpoint string
+}
+
+func (AWSCostCommand) GetMentionCommand() string {
+	return "awscost"
+}
+
+func (AWSCostCommand) GetHelp() string {
+	return "Get AWS Cost [date|from..to|last-7d|mtd] [by:service|account|region|usage-type|resource|tag:<key>] [top:N] [--format csv|md|chart]"
+}
+
+func (AWSCostCommand) HasHelp() bool {
+	return true
+}
+
+func (AWSCostCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a AWSCostCommand) Exec(d command.CommandData) {
+	a.runAWSCost(d)
+}
+
+// main logic.
+func (a AWSCostCommand) runAWSCost(d command.CommandData) {
+	source, err := NewCostSource(a.SourceType, a.FilePath)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[NewCostSource]\t`%s`", err.Error())
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+		return
+	}
+
+	// Use period from the message, or use yesterday.
+	opt, err := parseCostOption(d.TextOther, time.Now())
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Invalid option: [%s] `%s`", d.TextOther, err.Error())).Run()
+		return
+	}
+
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Getting costs on [%s]...", opt.Period.String())).Run()
+
+	// Get costs of AWS services.
+	report, err := source.FetchCosts(costQuery{
+		Period:   opt.Period,
+		GroupBy:  opt.GroupBy,
+		Services: a.Services,
+	})
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[FetchCosts]\t[%s]\t`%s`", source.GetName(), err.Error())
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+		return
+	}
+
+	switch opt.Format {
+	case costFormatCSV:
+		byt, err := report.FormatAsCSV()
+		if err != nil {
+			errMessage := fmt.Sprintf("[ERROR]\t[FormatAsCSV]\t`%s`", err.Error())
+			_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+			return
+		}
+		a.upload(d, byt, report.GetFileName("csv"))
+	case costFormatMarkdown:
+		a.upload(d, report.FormatAsMarkdown(), report.GetFileName("md"))
+	case costFormatChart:
+		a.replyChart(d, source, opt, report)
+	default:
+		// format costs data for Slack message
+		msg := fmt.Sprintf("```%s```", report.FormatAsOutputReport(opt.TopN))
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, msg).Run()
+	}
+}
+
+func (a AWSCostCommand) upload(d command.CommandData, byt []byte, filename string) {
+	err := command.NewUploadEngineTask(d.Engine, d.Channel, bytes.NewReader(byt), filename).Run()
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[NewUploadEngineTask]\t`%s`", err.Error())
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+	}
+}
+
+func (a AWSCostCommand) replyChart(d command.CommandData, source CostSource, opt costOption, report costReport) {
+	if !canCreateChart(a.ChartEndpoint) {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, "[ERROR]\t[replyChart]\t`chart endpoint is not set`").Run()
+		return
+	}
+
+	// some sources do not support daily costs, so fetch costs for each day.
+	if len(report.Daily) == 0 {
+		daily, err := fetchDailyCosts(source, costQuery{
+			Period:   opt.Period,
+			GroupBy:  opt.GroupBy,
+			Services: a.Services,
+		})
+		if err != nil {
+			errMessage := fmt.Sprintf("[ERROR]\t[fetchDailyCosts]\t[%s]\t`%s`", source.GetName(), err.Error())
+			_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+			return
+		}
+		report.Daily = daily
+	}
+
+	title := i18n.Message("[AWS Estimate Costs] %s", opt.Period.String())
+	url, err := createChartURLWithType(a.ChartEndpoint, title, chartTypeStacked, report.DailyDatapoints(opt.TopN))
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[createChartURLWithType]\t`%s`", err.Error())
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+		return
+	}
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, url).Run()
+}
+
+// fetchDailyCosts fetches costs for each day of the period.
+func fetchDailyCosts(source CostSource, q costQuery) (Datapoints, error) {
+	var list Datapoints
+	for dt := q.Period.Start; dt.Before(q.Period.End); dt = dt.AddDate(0, 0, 1) {
+		dq := q
+		dq.Period = newCostPeriod(dt, dt.AddDate(0, 0, 1))
+		report, err := source.FetchCosts(dq)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range report.Items {
+			list = append(list, Datapoint{
+				MetricName: item.Key,
+				Value:      item.Amount,
+				Time:       dt,
+			})
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,215 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+	"github.com/evalphobia/bobo-experiment/storage"
+)
+
+const defaultCostAnomalyStateFile = "awscost_anomaly.json"
+
+var _ command.CommandTemplate = CostAnomalyCommand{}
+
+// CostAnomalyCommand checks daily costs of each service against its rolling baseline.
+// It's meant to run once a day by scheduler with "quiet" argument, which posts only when anomalies are found.
+type CostAnomalyCommand struct {
+	// SourceType, FilePath and Services are same as AWSCostCommand.
+	SourceType string
+	FilePath   string
+	Services   []string
+
+	Factor     float64 // alert when a cost exceeds baseline * Factor.
+	MinAmount  float64 // noise floor; increases under this amount are ignored.
+	Window     int     // days of the rolling baseline.
+	MinHistory int     // minimum days of history to alert.
+	TopN       int     // number of usage types shown for each anomaly.
+	// StateFile is a file path to save the baseline.
+	// If it's empty, envvar AWSCOST_ANOMALY_STATE_FILE is used.
+	StateFile string
+}
+
+func (CostAnomalyCommand) GetMentionCommand() string {
+	return "awscost:anomaly"
+}
+
+func (CostAnomalyCommand) GetHelp() string {
+	return "Check AWS Cost anomalies against rolling baseline [date] [quiet]"
+}
+
+func (CostAnomalyCommand) HasHelp() bool {
+	return true
+}
+
+func (CostAnomalyCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a CostAnomalyCommand) Exec(d command.CommandData) {
+	a.runAWSCostAnomaly(d)
+}
+
+// guards the baseline file from concurrent runs.
+var costAnomalyMu sync.Mutex
+
+// main logic.
+func (a CostAnomalyCommand) runAWSCostAnomaly(d command.CommandData) {
+	source, err := NewCostSource(a.SourceType, a.FilePath)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[NewCostSource]\t`%s`", err.Error())
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+		return
+	}
+
+	quiet := false
+	dateText := ""
+	for _, w := range strings.Fields(d.TextOther) {
+		switch w {
+		case "quiet":
+			quiet = true
+		default:
+			dateText = w
+		}
+	}
+
+	period, err := parseCostPeriod(dateText, time.Now())
+	if err == nil && period.Days() != 1 {
+		err = fmt.Errorf("set a single date: [%s]", dateText)
+	}
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Invalid option: [%s] `%s`", d.TextOther, err.Error())).Run()
+		return
+	}
+
+	if !quiet {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Checking cost anomalies on [%s]...", period.String())).Run()
+	}
+
+	report, err := a.fetchCostAnomalies(source, period)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[fetchCostAnomalies]\t[%s]\t`%s`", source.GetName(), err.Error())
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+		return
+	}
+	if quiet && !report.HasAnomaly() {
+		return
+	}
+
+	msg := fmt.Sprintf("```%s```", report.FormatAsOutputReport(a.getTopN()))
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, msg).Run()
+}
+
+// fetchCostAnomalies compares costs of the day with the baseline, and updates the baseline.
+func (a CostAnomalyCommand) fetchCostAnomalies(source CostSource, period costPeriod) (costAnomalyReport, error) {
+	current, err := source.FetchCosts(costQuery{
+		Period:   period,
+		GroupBy:  costGroupByService,
+		Services: a.Services,
+	})
+	if err != nil {
+		return costAnomalyReport{}, err
+	}
+
+	costAnomalyMu.Lock()
+	defer costAnomalyMu.Unlock()
+
+	store := storage.NewJSONFile(a.getStateFile())
+	state := costBaselineState{}
+	if err := store.Load(&state); err != nil {
+		return costAnomalyReport{}, err
+	}
+
+	report := costAnomalyReport{
+		Period:    period,
+		Unit:      current.Unit,
+		Anomalies: findCostAnomalies(state, current, a.getFactor(), a.getMinAmount(), a.getMinHistory()),
+	}
+
+	state.Record(current, a.getWindow())
+	if err := store.Save(state); err != nil {
+		return report, err
+	}
+
+	// usage types are optional, because some sources don't support them.
+	for i := range report.Anomalies {
+		an := &report.Anomalies[i]
+		curr, prev, err := a.fetchUsageTypeCosts(source, period, an.Service)
+		if err != nil {
+			continue
+		}
+		an.setDrivers(curr, prev)
+	}
+	return report, nil
+}
+
+// fetchUsageTypeCosts fetches costs of the service grouped by usage type on the day and the previous day.
+func (a CostAnomalyCommand) fetchUsageTypeCosts(source CostSource, period costPeriod, service string) (current, previous costReport, err error) {
+	groupBy := costGroupBy{Dimension: "usage_type"}
+	current, err = source.FetchCosts(costQuery{
+		Period:   period,
+		GroupBy:  groupBy,
+		Services: []string{service},
+	})
+	if err != nil {
+		return current, previous, err
+	}
+
+	previous, err = source.FetchCosts(costQuery{
+		Period:   newCostPeriod(period.Start.AddDate(0, 0, -1), period.Start),
+		GroupBy:  groupBy,
+		Services: []string{service},
+	})
+	return current, previous, err
+}
+
+func (a CostAnomalyCommand) getFactor() float64 {
+	if a.Factor > 1 {
+		return a.Factor
+	}
+	return defaultCostAnomalyFactor
+}
+
+func (a CostAnomalyCommand) getMinAmount() float64 {
+	if a.MinAmount > 0 {
+		return a.MinAmount
+	}
+	return defaultCostAnomalyMinAmount
+}
+
+func (a CostAnomalyCommand) getWindow() int {
+	if a.Window > 0 {
+		return a.Window
+	}
+	return defaultCostAnomalyWindow
+}
+
+func (a CostAnomalyCommand) getMinHistory() int {
+	if a.MinHistory > 0 {
+		return a.MinHistory
+	}
+	return defaultCostAnomalyMinHistory
+}
+
+func (a CostAnomalyCommand) getTopN() int {
+	if a.TopN > 0 {
+		return a.TopN
+	}
+	return defaultCostAnomalyTopN
+}
+
+func (a CostAnomalyCommand) getStateFile() string {
+	switch {
+	case a.StateFile != "":
+		return a.StateFile
+	case os.Getenv("AWSCOST_ANOMALY_STATE_FILE") != "":
+		return os.Getenv("AWSCOST_ANOMALY_STATE_FILE")
+	}
+	return defaultCostAnomalyStateFile
+}
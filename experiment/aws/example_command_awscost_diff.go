@@ -0,0 +1,95 @@
+package aws
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var _ command.CommandTemplate = CostDiffCommand{}
+
+type CostDiffCommand struct {
+	// SourceType and FilePath are same as AWSCostCommand.
+	SourceType string
+	FilePath   string
+	Services   []string
+
+	MinAmount float64 // noise floor; changes under this amount are ignored.
+}
+
+func (CostDiffCommand) GetMentionCommand() string {
+	return "awscost:diff"
+}
+
+func (CostDiffCommand) GetHelp() string {
+	return "Get AWS Cost changes [dod|wow] [date] [min:N] [top:N]"
+}
+
+func (CostDiffCommand) HasHelp() bool {
+	return true
+}
+
+func (CostDiffCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a CostDiffCommand) Exec(d command.CommandData) {
+	a.runAWSCostDiff(d)
+}
+
+// main logic.
+func (a CostDiffCommand) runAWSCostDiff(d command.CommandData) {
+	source, err := NewCostSource(a.SourceType, a.FilePath)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[NewCostSource]\t`%s`", err.Error())
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+		return
+	}
+
+	opt, err := parseCostDiffOption(d.TextOther, time.Now())
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Invalid option: [%s] `%s`", d.TextOther, err.Error())).Run()
+		return
+	}
+	if !opt.HasMin {
+		opt.MinAmount = a.getMinAmount()
+	}
+
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Comparing costs of [%s] and [%s]...", opt.Current.String(), opt.Previous.String())).Run()
+
+	current, err := source.FetchCosts(costQuery{
+		Period:   opt.Current,
+		GroupBy:  costGroupByService,
+		Services: a.Services,
+	})
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[FetchCosts]\t[%s]\t`%s`", source.GetName(), err.Error())
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+		return
+	}
+	previous, err := source.FetchCosts(costQuery{
+		Period:   opt.Previous,
+		GroupBy:  costGroupByService,
+		Services: a.Services,
+	})
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[FetchCosts]\t[%s]\t`%s`", source.GetName(), err.Error())
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+		return
+	}
+
+	report := newCostDiffReport(current, previous, opt.MinAmount)
+	msg := fmt.Sprintf("```%s```", report.FormatAsOutputReport(opt.TopN))
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, msg).Run()
+}
+
+func (a CostDiffCommand) getMinAmount() float64 {
+	if a.MinAmount > 0 {
+		return a.MinAmount
+	}
+	return defaultCostDiffMinAmount
+}
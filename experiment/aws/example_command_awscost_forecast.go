@@ -0,0 +1,133 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var _ command.CommandTemplate = CostForecastCommand{}
+
+type CostForecastCommand struct {
+	// Budgets is monthly budget list.
+	// If it's empty, budgets are loaded from BudgetFile.
+	Budgets []CostBudget
+	// BudgetFile is a JSON file of []CostBudget.
+	// If it's empty, envvar AWSCOST_BUDGET_FILE is used.
+	BudgetFile string
+}
+
+func (CostForecastCommand) GetMentionCommand() string {
+	return "awscost:forecast"
+}
+
+func (CostForecastCommand) GetHelp() string {
+	return "Get AWS Cost forecast of this month and compare with budgets"
+}
+
+func (CostForecastCommand) HasHelp() bool {
+	return true
+}
+
+func (CostForecastCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a CostForecastCommand) Exec(d command.CommandData) {
+	a.runAWSCostForecast(d)
+}
+
+// main logic.
+func (a CostForecastCommand) runAWSCostForecast(d command.CommandData) {
+	budgets, err := a.getBudgets()
+	switch {
+	case err != nil:
+		errMessage := fmt.Sprintf("[ERROR]\t[getBudgets]\t`%s`", err.Error())
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+		return
+	case len(budgets) == 0:
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("No budget is configured. Set AWSCOST_BUDGET_FILE")).Run()
+		return
+	}
+
+	now := time.Now().In(time.UTC)
+	actualPeriod, forecastPeriod := newCostForecastPeriods(now)
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Getting cost forecast of [%s]...", now.Format("2006-01"))).Run()
+
+	results := make(costForecasts, 0, len(budgets))
+	for _, b := range budgets {
+		f, err := fetchCostForecast(b, actualPeriod, forecastPeriod)
+		if err != nil {
+			errMessage := fmt.Sprintf("[ERROR]\t[fetchCostForecast]\t[%s]\t`%s`", b.getName(), err.Error())
+			_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+			return
+		}
+		results = append(results, f)
+	}
+
+	msg := fmt.Sprintf("```%s```", results.FormatAsOutputReport(now))
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, msg).Run()
+}
+
+func (a CostForecastCommand) getBudgets() ([]CostBudget, error) {
+	if len(a.Budgets) != 0 {
+		return a.Budgets, nil
+	}
+
+	filePath := a.BudgetFile
+	if filePath == "" {
+		filePath = os.Getenv("AWSCOST_BUDGET_FILE")
+	}
+	if filePath == "" {
+		return nil, nil
+	}
+	return loadCostBudgetsFromFile(filePath)
+}
+
+func loadCostBudgetsFromFile(filePath string) ([]CostBudget, error) {
+	byt, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []CostBudget
+	if err := json.Unmarshal(byt, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func fetchCostForecast(b CostBudget, actualPeriod, forecastPeriod costPeriod) (costForecast, error) {
+	f := costForecast{
+		Budget:      b,
+		ElapsedDays: actualPeriod.Days(),
+		MonthDays:   actualPeriod.Days() + forecastPeriod.Days(),
+	}
+
+	filter := newCostExplorerFilter(b.Account, b.Service)
+	if actualPeriod.Days() > 0 {
+		report, err := fetchCostExplorerCostsWithFilter(actualPeriod, costGroupByService, filter)
+		if err != nil {
+			return f, err
+		}
+		f.Actual = report.Total
+		f.Unit = report.Unit
+	}
+
+	forecast, unit, err := fetchCostExplorerForecast(forecastPeriod, filter)
+	if err != nil {
+		return f, err
+	}
+	f.Forecast = forecast
+	if unit != "" {
+		f.Unit = unit
+	}
+	return f, nil
+}
@@ -0,0 +1,245 @@
+package faceplusplus
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheSize     = 50
+	defaultCacheDiskSize = 500
+)
+
+var resultCacheOnce sync.Once
+var faceppCache *resultCache
+
+// getResultCache returns the cache of Face++ responses.
+// Set envvar FACEPP_CACHE_DIR to save merged images on disk. Detected faces are kept only in memory.
+func getResultCache() *resultCache {
+	resultCacheOnce.Do(func() {
+		faceppCache = newResultCache(
+			getEnvInt("FACEPP_CACHE_SIZE", defaultCacheSize),
+			os.Getenv("FACEPP_CACHE_DIR"),
+			getEnvInt("FACEPP_CACHE_DISK_SIZE", defaultCacheDiskSize),
+		)
+	})
+	return faceppCache
+}
+
+// resultCache is a content-addressed LRU cache in memory and optional disk.
+type resultCache struct {
+	maxEntries     int
+	dir            string
+	maxDiskEntries int
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key       string
+	value     []byte
+	createdAt time.Time
+}
+
+func newResultCache(maxEntries int, dir string, maxDiskEntries int) *resultCache {
+	return &resultCache{
+		maxEntries:     maxEntries,
+		dir:            dir,
+		maxDiskEntries: maxDiskEntries,
+		ll:             list.New(),
+		items:          make(map[string]*list.Element),
+	}
+}
+
+// get returns the value which is newer than maxAge. (0 means no limit)
+func (c *resultCache) get(key string, maxAge time.Duration) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*cacheEntry)
+		if isExpired(e.createdAt, maxAge) {
+			c.ll.Remove(elem)
+			delete(c.items, key)
+			return nil, false
+		}
+		c.ll.MoveToFront(elem)
+		return e.value, true
+	}
+
+	if c.dir == "" {
+		return nil, false
+	}
+	path := c.getFilePath(key)
+	byt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	createdAt, value, ok := decodeCacheFile(byt)
+	if !ok || isExpired(createdAt, maxAge) {
+		os.Remove(path)
+		return nil, false
+	}
+	// modtime of the file is used for LRU.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	c.addMemory(key, value, createdAt)
+	return value, true
+}
+
+func (c *resultCache) set(key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.addMemory(key, value, now)
+	if c.dir == "" {
+		return nil
+	}
+	return c.saveFile(key, encodeCacheFile(now, value))
+}
+
+// setMemory saves the value only in memory, for data which must not be left on disk.
+func (c *resultCache) setMemory(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addMemory(key, value, time.Now())
+}
+
+// deleteMemoryIf removes the entries in memory which match the condition.
+func (c *resultCache) deleteMemoryIf(match func(key string, value []byte) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.items {
+		if match(key, elem.Value.(*cacheEntry).value) {
+			c.ll.Remove(elem)
+			delete(c.items, key)
+		}
+	}
+}
+
+func (c *resultCache) addMemory(key string, value []byte, createdAt time.Time) {
+	if elem, ok := c.items[key]; ok {
+		c.ll.MoveToFront(elem)
+		e := elem.Value.(*cacheEntry)
+		e.value = value
+		e.createdAt = createdAt
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&cacheEntry{
+		key:       key,
+		value:     value,
+		createdAt: createdAt,
+	})
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *resultCache) saveFile(key string, value []byte) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(c.dir, "tmp.")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.getFilePath(key)); err != nil {
+		return err
+	}
+	return c.evictFiles()
+}
+
+// evictFiles removes least recently used files over maxDiskEntries.
+func (c *resultCache) evictFiles() error {
+	if c.maxDiskEntries <= 0 {
+		return nil
+	}
+	files, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	list := make([]os.FileInfo, 0, len(files))
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".cache") {
+			list = append(list, f)
+		}
+	}
+	if len(list) <= c.maxDiskEntries {
+		return nil
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ModTime().Before(list[j].ModTime())
+	})
+	for _, f := range list[:len(list)-c.maxDiskEntries] {
+		os.Remove(filepath.Join(c.dir, f.Name()))
+	}
+	return nil
+}
+
+func (c *resultCache) getFilePath(key string) string {
+	return filepath.Join(c.dir, key+".cache")
+}
+
+// encodeCacheFile adds the created time as the first line.
+func encodeCacheFile(createdAt time.Time, value []byte) []byte {
+	header := strconv.FormatInt(createdAt.Unix(), 10) + "\n"
+	return append([]byte(header), value...)
+}
+
+func decodeCacheFile(byt []byte) (createdAt time.Time, value []byte, ok bool) {
+	i := bytes.IndexByte(byt, '\n')
+	if i < 0 {
+		return time.Time{}, nil, false
+	}
+	unix, err := strconv.ParseInt(string(byt[:i]), 10, 64)
+	if err != nil {
+		return time.Time{}, nil, false
+	}
+	return time.Unix(unix, 0), byt[i+1:], true
+}
+
+func isExpired(createdAt time.Time, maxAge time.Duration) bool {
+	return maxAge > 0 && time.Since(createdAt) > maxAge
+}
+
+// hashBytes returns sha256 hex of the data, which is used for cache keys.
+func hashBytes(byt []byte) string {
+	sum := sha256.Sum256(byt)
+	return hex.EncodeToString(sum[:])
+}
+
+func getEnvInt(name string, defaultValue int) int {
+	v, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
@@ -0,0 +1,79 @@
+package faceplusplus
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var _ command.CommandTemplate = &FaceCommand{}
+
+// FaceCommand detects faces and their attributes by Face++.
+type FaceCommand struct{}
+
+func (*FaceCommand) GetMentionCommand() string {
+	return "face"
+}
+
+func (*FaceCommand) GetHelp() string {
+	return "Detect faces from image URL or attached image by Face++"
+}
+
+func (*FaceCommand) HasHelp() bool {
+	return true
+}
+
+func (*FaceCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (*FaceCommand) Exec(d command.CommandData) {
+	runDetectFace(d)
+}
+
+// main logic
+func runDetectFace(d command.CommandData) {
+	images, err := getFaceImages(d)
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR]\t[getFaceImages]\t`%s`", err.Error())).Run()
+		return
+	}
+	img := images[0]
+
+	faces, err := detectFaces(d.SenderID, img)
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR]\t[detectFaces]\t`%s`", err.Error())).Run()
+		return
+	}
+	if len(faces) == 0 {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("No face is detected")).Run()
+		return
+	}
+	saveLastDetection(d.Channel, faceDetection{
+		Image: img,
+		Faces: faces,
+	})
+
+	lines := make([]string, 0, len(faces)+1)
+	lines = append(lines, i18n.Message("Detected %d faces", len(faces)))
+	for i, f := range faces {
+		lines = append(lines, formatFaceSummary(i+1, f))
+	}
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, strings.Join(lines, "\n")).Run()
+
+	data, err := annotateFaces(img.Data, faces)
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR]\t[annotateFaces]\t`%s`", err.Error())).Run()
+		return
+	}
+	err = command.NewUploadEngineTask(d.Engine, d.Channel, bytes.NewBuffer(data), "faces.jpg").Run()
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR]\t[NewUploadEngineTask]\t`%s`", err.Error())).Run()
+		return
+	}
+}
@@ -0,0 +1,80 @@
+package faceplusplus
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var _ command.CommandTemplate = FaceForgetCommand{}
+
+// FaceForgetCommand deletes the sender's faces and consent from FaceSet.
+// Cached detections of the faces are removed too, but cached merged images are kept until the LRU evicts them.
+// e.g.) "face:forget me"
+type FaceForgetCommand struct {
+	FaceSet *FaceSet
+}
+
+func (FaceForgetCommand) GetMentionCommand() string {
+	return "face:forget"
+}
+
+func (FaceForgetCommand) GetHelp() string {
+	return "Delete your registered faces"
+}
+
+func (FaceForgetCommand) HasHelp() bool {
+	return true
+}
+
+func (FaceForgetCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a FaceForgetCommand) Exec(d command.CommandData) {
+	c := a.runFaceForget(d)
+	c.Exec()
+}
+
+// main logic.
+func (a FaceForgetCommand) runFaceForget(d command.CommandData) command.Command {
+	c := command.Command{}
+
+	if a.FaceSet == nil {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, "[ERROR]\t[FaceForgetCommand]\t`FaceSet is not set`")
+		c.Add(task)
+		return c
+	}
+	if err := a.FaceSet.init(); err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[FaceSet.init]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	if userID, ok := getTargetUserID(d, d.TextOther); !ok || userID != d.SenderID {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("You can delete only your own face. Run `face:forget me`"))
+		c.Add(task)
+		return c
+	}
+
+	ok, err := a.FaceSet.forget(d.SenderID)
+	switch {
+	case err != nil:
+		errMessage := fmt.Sprintf("[ERROR]\t[forget]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	case !ok:
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Your face is not registered"))
+		c.Add(task)
+		return c
+	}
+
+	task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Deleted your faces"))
+	c.Add(task)
+	return c
+}
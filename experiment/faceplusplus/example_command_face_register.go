@@ -0,0 +1,148 @@
+package faceplusplus
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/eure/bobo/command"
+	"github.com/eure/bobo/library"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var _ command.CommandTemplate = FaceRegisterCommand{}
+
+// FaceRegisterCommand registers the sender's face to FaceSet.
+// e.g.) "face:register me --agree <image>"
+type FaceRegisterCommand struct {
+	FaceSet *FaceSet
+}
+
+func (FaceRegisterCommand) GetMentionCommand() string {
+	return "face:register"
+}
+
+func (FaceRegisterCommand) GetHelp() string {
+	return "Register your face to identify you by face:who"
+}
+
+func (FaceRegisterCommand) HasHelp() bool {
+	return true
+}
+
+func (FaceRegisterCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a FaceRegisterCommand) Exec(d command.CommandData) {
+	c := a.runFaceRegister(d)
+	c.Exec()
+}
+
+// main logic.
+func (a FaceRegisterCommand) runFaceRegister(d command.CommandData) command.Command {
+	c := command.Command{}
+
+	if a.FaceSet == nil {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, "[ERROR]\t[FaceRegisterCommand]\t`FaceSet is not set`")
+		c.Add(task)
+		return c
+	}
+	if err := a.FaceSet.init(); err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[FaceSet.init]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	fields := strings.Fields(d.TextOther)
+	if len(fields) == 0 {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Usage: `face:register me --agree <image>`"))
+		c.Add(task)
+		return c
+	}
+	// users can register only themselves for consent.
+	if !isSenderTarget(d, fields) {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("You can register only your own face"))
+		c.Add(task)
+		return c
+	}
+
+	if !hasField(fields, "--agree") && !a.FaceSet.hasConsent(d.SenderID) {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Your face image is sent to Face++ and the face data is stored to identify you by `face:who`. You can delete it anytime by `face:forget me`. If you agree, run `face:register me --agree <image>`"))
+		c.Add(task)
+		return c
+	}
+
+	images, err := getFaceImages(d)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[getFaceImages]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	faces, err := detectFaces(d.SenderID, images[0])
+	switch {
+	case err != nil:
+		errMessage := fmt.Sprintf("[ERROR]\t[detectFaces]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	case len(faces) != 1:
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Use an image which has only your face. (detected: %d)", len(faces)))
+		c.Add(task)
+		return c
+	}
+
+	count, err := a.FaceSet.register(d.SenderID, faces[0].FaceToken)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[register]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Registered your face. (%d images)", count))
+	c.Add(task)
+	return c
+}
+
+// getTargetUserID returns user id from "me" or "@mention".
+func getTargetUserID(d command.CommandData, text string) (string, bool) {
+	text = library.TrimSigns(strings.TrimSpace(text))
+	switch {
+	case text == "me":
+		return d.SenderID, true
+	case strings.HasPrefix(text, "@"):
+		return strings.TrimPrefix(text, "@"), true
+	}
+	return "", false
+}
+
+// isSenderTarget checks the fields have "me" or "@mention" of the sender, and no other users.
+// The target can be placed anywhere in the fields. e.g.) "--agree me <image>"
+func isSenderTarget(d command.CommandData, fields []string) bool {
+	found := false
+	for _, f := range fields {
+		userID, ok := getTargetUserID(d, f)
+		switch {
+		case !ok:
+			continue
+		case userID != d.SenderID:
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+func hasField(fields []string, s string) bool {
+	for _, f := range fields {
+		if f == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package faceplusplus
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+// max faces to search in an image.
+const maxFaceWhoFaces = 10
+
+var _ command.CommandTemplate = FaceWhoCommand{}
+
+// FaceWhoCommand identifies registered users in the image.
+type FaceWhoCommand struct {
+	FaceSet *FaceSet
+}
+
+func (FaceWhoCommand) GetMentionCommand() string {
+	return "face:who"
+}
+
+func (FaceWhoCommand) GetHelp() string {
+	return "Identify registered people in the image"
+}
+
+func (FaceWhoCommand) HasHelp() bool {
+	return true
+}
+
+func (FaceWhoCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a FaceWhoCommand) Exec(d command.CommandData) {
+	c := a.runFaceWho(d)
+	c.Exec()
+}
+
+// main logic.
+func (a FaceWhoCommand) runFaceWho(d command.CommandData) command.Command {
+	c := command.Command{}
+
+	if a.FaceSet == nil {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, "[ERROR]\t[FaceWhoCommand]\t`FaceSet is not set`")
+		c.Add(task)
+		return c
+	}
+	if err := a.FaceSet.init(); err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[FaceSet.init]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	images, err := getFaceImages(d)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[getFaceImages]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+	img := images[0]
+
+	faces, err := detectFaces(d.SenderID, img)
+	switch {
+	case err != nil:
+		errMessage := fmt.Sprintf("[ERROR]\t[detectFaces]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	case len(faces) == 0:
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("No face is detected"))
+		c.Add(task)
+		return c
+	case len(faces) > maxFaceWhoFaces:
+		faces = faces[:maxFaceWhoFaces]
+	}
+	saveLastDetection(d.Channel, faceDetection{
+		Image: img,
+		Faces: faces,
+	})
+
+	lines := make([]string, len(faces))
+	for i, f := range faces {
+		userID, confidence, err := a.FaceSet.identify(d.SenderID, f.FaceToken)
+		switch {
+		case err != nil:
+			lines[i] = fmt.Sprintf("#%d\t[ERROR]\t[identify]\t`%s`", i+1, err.Error())
+		case userID == "":
+			lines[i] = fmt.Sprintf("#%d\t%s", i+1, i18n.Message("unknown"))
+		default:
+			lines[i] = fmt.Sprintf("#%d\t<@%s>\t(%.1f)", i+1, userID, confidence)
+		}
+	}
+	task := command.NewReplyEngineTask(d.Engine, d.Channel, strings.Join(lines, "\n"))
+	c.Add(task)
+
+	data, err := annotateFaces(img.Data, faces)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[annotateFaces]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+	c.Add(command.NewUploadEngineTask(d.Engine, d.Channel, bytes.NewBuffer(data), "faces.jpg"))
+	return c
+}
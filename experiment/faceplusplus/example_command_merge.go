@@ -1,19 +1,11 @@
 package faceplusplus
 
 import (
-	"bytes"
-	"errors"
 	"fmt"
 	"regexp"
-	"strconv"
-	"strings"
 	"sync"
 
-	"github.com/evalphobia/go-face-plusplus/beautify"
-	"github.com/evalphobia/go-face-plusplus/config"
-
 	"github.com/eure/bobo/command"
-	"github.com/eure/bobo/library"
 	"github.com/evalphobia/bobo-experiment/i18n"
 )
 
@@ -74,42 +66,27 @@ func (m *MergeCommand) runMergeFace(d command.CommandData) {
 		return
 	}
 
-	url := strings.Fields(d.TextOther)
-	if len(url) < 2 {
-		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Set Two URLs")).Run()
+	args, ok := parseMergeArgs(d.TextOther)
+	if !ok {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Invalid URL. It must begin with [http/https]")).Run()
 		return
 	}
 
-	// url validation
-	url1 := library.TrimSigns(url[0])
-	url2 := library.TrimSigns(url[1])
+	images, err := getFaceImages(d)
 	switch {
-	case !strings.HasPrefix(url1, "http"),
-		!strings.HasPrefix(url2, "http"):
-		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Invalid URL. It must begin with [http/https]")).Run()
+	case err != nil:
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [getFaceImages] `%s`", err.Error())).Run()
 		return
-	}
-
-	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Merging...")).Run()
-
-	var mergeRate int
-	if len(url) >= 3 {
-		mergeRateStr := url[2]
-		mergeRate, _ = strconv.Atoi(mergeRateStr)
-	}
-
-	resp, err := mergeFaceImage(url2, url1, m.getMergeRate(mergeRate))
-	if err != nil {
-		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [mergeFaceImage] `%s`", err.Error())).Run()
+	case len(images) < 2:
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Set two image URLs or attach two images")).Run()
 		return
 	}
 
-	buf := bytes.NewBuffer(resp)
-	err = command.NewUploadEngineTask(d.Engine, d.Channel, buf, "result.jpg").Run()
-	if err != nil {
-		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [NewUploadEngineTask] `%s`", err.Error())).Run()
-		return
-	}
+	runMergeRequest(d, &mergeRequest{
+		Template:  newMergeInput(images[0], 1, args),
+		Merge:     newMergeInput(images[1], 2, args),
+		MergeRate: m.getMergeRate(args.MergeRate),
+	})
 }
 
 func (m *MergeCommand) isInBlacklist(name string) bool {
@@ -138,25 +115,3 @@ func (m *MergeCommand) getMergeRate(mergeRate int) int {
 	const defaultMergeRate = 75 // 75%
 	return defaultMergeRate
 }
-
-// execute merge face API.
-func mergeFaceImage(fromURL, toURL string, mergeRate int) ([]byte, error) {
-	svc, err := beautify.New(config.Config{})
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := svc.MergeFace(beautify.MergeFaceRequest{
-		TemplateURL: toURL,
-		MergeURL:    fromURL,
-		MergeRate:   mergeRate,
-	})
-	switch {
-	case err != nil:
-		return nil, err
-	case resp.ErrorMessage != "":
-		return nil, errors.New(resp.ErrorMessage)
-	}
-
-	return resp.GetResultImage()
-}
@@ -0,0 +1,49 @@
+package faceplusplus
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var _ command.CommandTemplate = &MergeFaceCommand{}
+
+// MergeFaceCommand chooses the face for the merge which waits for selection.
+type MergeFaceCommand struct{}
+
+func (*MergeFaceCommand) GetMentionCommand() string {
+	return "merge:face"
+}
+
+func (*MergeFaceCommand) GetHelp() string {
+	return "Choose the face number for merge"
+}
+
+func (*MergeFaceCommand) HasHelp() bool {
+	return true
+}
+
+func (*MergeFaceCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (*MergeFaceCommand) Exec(d command.CommandData) {
+	num, err := strconv.Atoi(strings.TrimSpace(d.TextOther))
+	if err != nil || num < 1 {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Set a face number")).Run()
+		return
+	}
+
+	req, ok := popPendingMerge(d)
+	if !ok {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("No merge is waiting for face selection")).Run()
+		return
+	}
+
+	req.selectFace(num)
+	runMergeRequest(d, req)
+}
@@ -1,16 +1,13 @@
 package faceplusplus
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"math/rand"
 	"regexp"
-	"strconv"
-	"strings"
 	"sync"
 
 	"github.com/eure/bobo/command"
-	"github.com/eure/bobo/library"
 	"github.com/evalphobia/bobo-experiment/i18n"
 )
 
@@ -75,40 +72,24 @@ func (m *MergeTargetCommand) runMergeFace(d command.CommandData) {
 		return
 	}
 
-	url := strings.Fields(d.TextOther)
-	if len(url) < 1 {
-		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Set a URL")).Run()
-		return
-	}
-
-	// url validation
-	url1 := library.TrimSigns(url[0])
-	switch {
-	case !strings.HasPrefix(url1, "http"):
+	args, ok := parseMergeArgs(d.TextOther)
+	if !ok {
 		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Invalid URL. It must begin with [http/https]")).Run()
 		return
 	}
 
-	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Merging...")).Run()
-
-	var mergeRate int
-	if len(url) >= 2 {
-		mergeRateStr := url[1]
-		mergeRate, _ = strconv.Atoi(mergeRateStr)
-	}
-
-	resp, err := m.mergeFaceImage(url1, m.getMergeRate(mergeRate))
+	images, err := getFaceImages(d)
 	if err != nil {
-		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [mergeFaceImage] `%s`", err.Error())).Run()
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [getFaceImages] `%s`", err.Error())).Run()
 		return
 	}
 
-	buf := bytes.NewBuffer(resp)
-	err = command.NewUploadEngineTask(d.Engine, d.Channel, buf, "result.jpg").Run()
+	req, err := m.newMergeRequest(images[0], args)
 	if err != nil {
-		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [NewUploadEngineTask] `%s`", err.Error())).Run()
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [newMergeRequest] `%s`", err.Error())).Run()
 		return
 	}
+	runMergeRequest(d, req)
 }
 
 func (m *MergeTargetCommand) isInBlacklist(name string) bool {
@@ -138,14 +119,34 @@ func (m *MergeTargetCommand) getMergeRate(mergeRate int) int {
 	return defaultMergeRate
 }
 
-func (m *MergeTargetCommand) mergeFaceImage(url1 string, mergeRate int) ([]byte, error) {
-	imgList := m.TargetURLs
-	url2 := imgList[rand.Intn(len(imgList))]
+// newMergeRequest merges the user's image with a random target image.
+func (m *MergeTargetCommand) newMergeRequest(img *faceImage, args mergeArgs) (*mergeRequest, error) {
+	imgList := make([]string, 0, len(m.TargetURLs))
+	for _, u := range m.TargetURLs {
+		if validateTargetURL(u) == nil {
+			imgList = append(imgList, u)
+		}
+	}
+	if len(imgList) == 0 {
+		return nil, errors.New(i18n.Message("Merge target [%s] has no valid image", m.TargetName))
+	}
+
+	target := &mergeInput{
+		faceDetection: faceDetection{Image: &faceImage{
+			URL:  imgList[rand.Intn(len(imgList))],
+			Name: m.TargetName,
+		}},
+		AutoSelect: true,
+	}
 
-	fromURL, toURL := url1, url2
+	from, to := newMergeInput(img, 1, args), target
 	if m.MergeFromTarget {
-		fromURL, toURL = toURL, fromURL
+		from, to = to, from
 	}
 
-	return mergeFaceImage(fromURL, toURL, mergeRate)
+	return &mergeRequest{
+		Template:  to,
+		Merge:     from,
+		MergeRate: m.getMergeRate(args.MergeRate),
+	}, nil
 }
@@ -0,0 +1,116 @@
+package faceplusplus
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/eure/bobo/command"
+	"github.com/eure/bobo/library"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var _ command.CommandTemplate = MergeTargetAddCommand{}
+
+// MergeTargetAddCommand adds an image to the merge target.
+// Added images are saved permanently, so limit users by Whitelist or Blacklist.
+// e.g.) "merge-target:add cat https://example.com/cat.jpg"
+type MergeTargetAddCommand struct {
+	Targets *MergeTargets
+
+	UseWhitelist bool
+	Whitelist    []string
+	UseBlacklist bool
+	Blacklist    []string
+}
+
+func (MergeTargetAddCommand) GetMentionCommand() string {
+	return "merge-target:add"
+}
+
+func (MergeTargetAddCommand) GetHelp() string {
+	return "Add an image to merge target"
+}
+
+func (MergeTargetAddCommand) HasHelp() bool {
+	return true
+}
+
+func (MergeTargetAddCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a MergeTargetAddCommand) Exec(d command.CommandData) {
+	c := a.runMergeTargetAdd(d)
+	c.Exec()
+}
+
+// main logic.
+func (a MergeTargetAddCommand) runMergeTargetAdd(d command.CommandData) command.Command {
+	c := command.Command{}
+
+	switch {
+	case a.isInBlacklist(d.SenderName),
+		!a.isInWhitelist(d.SenderName):
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("No!"))
+		c.Add(task)
+		return c
+	}
+
+	if a.Targets == nil {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, "[ERROR]\t[MergeTargetAddCommand]\t`Targets is not set`")
+		c.Add(task)
+		return c
+	}
+	if err := a.Targets.init(); err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[MergeTargets.init]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	fields := strings.Fields(d.TextOther)
+	if len(fields) < 2 {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Usage: `merge-target:add <name> <image url>`"))
+		c.Add(task)
+		return c
+	}
+	name := strings.ToLower(fields[0])
+
+	count, err := a.Targets.add(name, library.TrimSigns(fields[1]))
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[add]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Added the image to merge target [%s]. Run `merge-%s <image>`. (%d images)", name, name, count))
+	c.Add(task)
+	return c
+}
+
+func (a MergeTargetAddCommand) isInBlacklist(name string) bool {
+	if !a.UseBlacklist {
+		return false
+	}
+	for _, s := range a.Blacklist {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (a MergeTargetAddCommand) isInWhitelist(name string) bool {
+	if !a.UseWhitelist {
+		return true
+	}
+	for _, s := range a.Whitelist {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package faceplusplus
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/eure/bobo/command"
+)
+
+var _ command.CommandTemplate = &MergeTargetsCommand{}
+
+// "merge-<name>" mentions are not in the mention map, so they are caught by regexp.
+var mergeTargetsRegexp = regexp.MustCompile(`^(<@\w+>\s+)?merge-\w+`)
+
+// MergeTargetsCommand runs "merge-<name>" commands of MergeTargets.
+// Targets can be added at runtime, so it's matched by regexp instead of mention command.
+type MergeTargetsCommand struct {
+	Targets *MergeTargets
+
+	MergeRate    int
+	UseWhitelist bool
+	Whitelist    []string
+	UseBlacklist bool
+	Blacklist    []string
+}
+
+func (*MergeTargetsCommand) GetMentionCommand() string {
+	return ""
+}
+
+// GetHelp lists the targets only when they are loaded, not to create the config file by help.
+func (m *MergeTargetsCommand) GetHelp() string {
+	if m.Targets == nil {
+		return "Merge face images with a target"
+	}
+	names := m.Targets.names()
+	if len(names) == 0 {
+		return "Merge face images with a target"
+	}
+	return "Merge face images with a target: " + strings.Join(names, ", ")
+}
+
+func (*MergeTargetsCommand) HasHelp() bool {
+	return true
+}
+
+func (*MergeTargetsCommand) GetRegexp() *regexp.Regexp {
+	return mergeTargetsRegexp
+}
+
+func (m *MergeTargetsCommand) Exec(d command.CommandData) {
+	if !d.HasMyMention() {
+		return
+	}
+	name, ok := parseMergeTargetName(d.TextCommand)
+	if !ok {
+		return
+	}
+
+	if m.Targets == nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, "[ERROR] [MergeTargetsCommand] `Targets is not set`").Run()
+		return
+	}
+	if err := m.Targets.init(); err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [MergeTargets.init] `%s`", err.Error())).Run()
+		return
+	}
+
+	target, err := m.Targets.get(name)
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, err.Error()).Run()
+		return
+	}
+
+	comm := &MergeTargetCommand{
+		TargetName:      name,
+		TargetURLs:      target.URLs,
+		MergeFromTarget: target.MergeFromTarget,
+		MergeRate:       m.MergeRate,
+		UseWhitelist:    m.UseWhitelist,
+		Whitelist:       m.Whitelist,
+		UseBlacklist:    m.UseBlacklist,
+		Blacklist:       m.Blacklist,
+	}
+	comm.Exec(d)
+}
@@ -0,0 +1,94 @@
+package faceplusplus
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+// max users to show in usage.
+const maxUsageUsers = 5
+
+var _ command.CommandTemplate = FaceppUsageCommand{}
+
+// FaceppUsageCommand shows today's Face++ calls, quotas and cache hits.
+type FaceppUsageCommand struct{}
+
+func (FaceppUsageCommand) GetMentionCommand() string {
+	return "faceplusplus:usage"
+}
+
+func (FaceppUsageCommand) GetHelp() string {
+	return "Show today's Face++ API usage"
+}
+
+func (FaceppUsageCommand) HasHelp() bool {
+	return true
+}
+
+func (FaceppUsageCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a FaceppUsageCommand) Exec(d command.CommandData) {
+	c := a.runUsage(d)
+	c.Exec()
+}
+
+// main logic.
+func (FaceppUsageCommand) runUsage(d command.CommandData) command.Command {
+	c := command.Command{}
+
+	usage := getUsageCounter()
+	state := usage.getState()
+
+	lines := make([]string, 0, 16)
+	lines = append(lines, i18n.Message("Face++ usage of [%s]", state.Date))
+	lines = append(lines, fmt.Sprintf("- %s:\t%s", i18n.Message("Total"), formatQuota(state.Total, usage.DailyLimit)))
+	lines = append(lines, fmt.Sprintf("- %s:\t%s", i18n.Message("You"), formatQuota(state.Users[d.SenderID], usage.UserDailyLimit)))
+	lines = append(lines, fmt.Sprintf("- %s:\t%d", i18n.Message("Cache hits"), state.CacheHits))
+
+	apis := make([]string, 0, len(state.APIs))
+	for name := range state.APIs {
+		apis = append(apis, name)
+	}
+	sort.Strings(apis)
+	for _, name := range apis {
+		lines = append(lines, fmt.Sprintf("- %s:\t%d", name, state.APIs[name]))
+	}
+
+	users := make([]string, 0, len(state.Users))
+	for id := range state.Users {
+		users = append(users, id)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return state.Users[users[i]] > state.Users[users[j]]
+	})
+	if len(users) > maxUsageUsers {
+		users = users[:maxUsageUsers]
+	}
+	for _, id := range users {
+		name := id
+		if u, err := d.Engine.GetUserByID(id); err == nil {
+			name = u.Name
+		}
+		lines = append(lines, fmt.Sprintf("- %s:\t%d", name, state.Users[id]))
+	}
+
+	task := command.NewReplyEngineTask(d.Engine, d.Channel, strings.Join(lines, "\n"))
+	c.Add(task)
+	return c
+}
+
+// formatQuota returns "count/limit", or count when it has no limit.
+func formatQuota(count, limit int) string {
+	if limit <= 0 {
+		return fmt.Sprint(count)
+	}
+	return fmt.Sprintf("%d/%d", count, limit)
+}
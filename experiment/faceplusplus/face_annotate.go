@@ -0,0 +1,105 @@
+package faceplusplus
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // decoder
+	"strconv"
+
+	"github.com/evalphobia/go-face-plusplus/face"
+)
+
+var (
+	annotateColor      = color.RGBA{R: 0xff, G: 0x40, B: 0x40, A: 0xff}
+	annotateLabelColor = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// 3x5 bitmap of digits for labels.
+var digitFont = [10][5]string{
+	{"###", "#.#", "#.#", "#.#", "###"},
+	{".#.", "##.", ".#.", ".#.", "###"},
+	{"###", "..#", "###", "#..", "###"},
+	{"###", "..#", "###", "..#", "###"},
+	{"#.#", "#.#", "###", "..#", "..#"},
+	{"###", "#..", "###", "..#", "###"},
+	{"###", "#..", "###", "#.#", "###"},
+	{"###", "..#", "..#", "..#", "..#"},
+	{"###", "#.#", "###", "#.#", "###"},
+	{"###", "#.#", "###", "..#", "###"},
+}
+
+// annotateFaces draws rectangles and numbers of the faces on the image, and returns JPEG binary.
+func annotateFaces(data []byte, faces []face.Face) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Src)
+
+	// scale lines and labels by the image size.
+	size := bounds.Dx()
+	if bounds.Dy() > size {
+		size = bounds.Dy()
+	}
+	thickness := size/300 + 1
+	scale := size/150 + 2
+
+	for i, f := range faces {
+		rect := image.Rect(f.Left, f.Top, f.Left+f.Width, f.Top+f.Height).Add(bounds.Min)
+		drawRectangle(dst, rect, thickness, annotateColor)
+		drawLabel(dst, rect.Min, strconv.Itoa(i+1), scale)
+	}
+
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawRectangle(img *image.RGBA, r image.Rectangle, thickness int, c color.Color) {
+	u := image.NewUniform(c)
+	draw.Draw(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+thickness), u, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(r.Min.X, r.Max.Y-thickness, r.Max.X, r.Max.Y), u, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+thickness, r.Max.Y), u, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(r.Max.X-thickness, r.Min.Y, r.Max.X, r.Max.Y), u, image.Point{}, draw.Src)
+}
+
+// drawLabel draws the number above the point, or below it when there is no space.
+func drawLabel(img *image.RGBA, pt image.Point, text string, scale int) {
+	const charWidth, charHeight = 3, 5
+	padding := scale
+	w := len(text)*(charWidth+1)*scale - scale + padding*2
+	h := charHeight*scale + padding*2
+
+	label := image.Rect(pt.X, pt.Y-h, pt.X+w, pt.Y)
+	if label.Min.Y < img.Bounds().Min.Y {
+		label = label.Add(image.Pt(0, h))
+	}
+	draw.Draw(img, label, image.NewUniform(annotateColor), image.Point{}, draw.Src)
+
+	fg := image.NewUniform(annotateLabelColor)
+	x := label.Min.X + padding
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			continue
+		}
+		for row, line := range digitFont[r-'0'] {
+			for col, dot := range line {
+				if dot != '#' {
+					continue
+				}
+				px := x + col*scale
+				py := label.Min.Y + padding + row*scale
+				draw.Draw(img, image.Rect(px, py, px+scale, py+scale), fg, image.Point{}, draw.Src)
+			}
+		}
+		x += (charWidth + 1) * scale
+	}
+}
@@ -0,0 +1,180 @@
+package faceplusplus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evalphobia/go-face-plusplus/config"
+	"github.com/evalphobia/go-face-plusplus/face"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var detectAttributes = []face.ReturnAttribute{
+	face.AttributeGender,
+	face.AttributeAge,
+	face.AttributeSmiling,
+	face.AttributeHeadPose,
+	face.AttributeEmotion,
+	face.AttributeBeauty,
+}
+
+// execute detect API.
+// Faces are sorted from left to right, and the number of the face is index+1.
+func detectFaces(userID string, img *faceImage) ([]face.Face, error) {
+	base64Str, err := img.getBase64()
+	if err != nil {
+		return nil, err
+	}
+
+	// face tokens expire in 72 hours on Face++.
+	const detectCacheTTL = 24 * time.Hour
+	cacheKey := detectCacheKeyPrefix + hashBytes(img.Data)
+	var faces []face.Face
+	if byt, ok := getResultCache().get(cacheKey, detectCacheTTL); ok && json.Unmarshal(byt, &faces) == nil {
+		getUsageCounter().hit(userID, apiNameDetect)
+		return faces, nil
+	}
+
+	if err := getUsageCounter().use(userID, apiNameDetect, true); err != nil {
+		return nil, err
+	}
+	svc, err := face.New(config.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := svc.DetectByBase64(base64Str, face.WithReturnAttributes(detectAttributes...))
+	switch {
+	case err != nil:
+		return nil, err
+	case resp.ErrorMessage != "":
+		return nil, errors.New(resp.ErrorMessage)
+	}
+
+	faces = resp.Faces
+	sort.SliceStable(faces, func(i, j int) bool {
+		if faces[i].Left != faces[j].Left {
+			return faces[i].Left < faces[j].Left
+		}
+		return faces[i].Top < faces[j].Top
+	})
+
+	// face tokens and attributes are personal data, so they are not saved on disk.
+	if byt, err := json.Marshal(faces); err == nil {
+		getResultCache().setMemory(cacheKey, byt)
+	}
+	return faces, nil
+}
+
+const detectCacheKeyPrefix = "detect-"
+
+// forgetFaceTokens removes cached detect results and the last detections which contain the face tokens.
+func forgetFaceTokens(faceTokens []string) {
+	hasToken := func(faces []face.Face) bool {
+		for _, f := range faces {
+			for _, token := range faceTokens {
+				if f.FaceToken == token {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	getResultCache().deleteMemoryIf(func(key string, value []byte) bool {
+		if !strings.HasPrefix(key, detectCacheKeyPrefix) {
+			return false
+		}
+		var faces []face.Face
+		if err := json.Unmarshal(value, &faces); err != nil {
+			return true
+		}
+		return hasToken(faces)
+	})
+
+	lastDetectionMu.Lock()
+	defer lastDetectionMu.Unlock()
+	for channel, r := range lastDetections {
+		if hasToken(r.Faces) {
+			delete(lastDetections, channel)
+		}
+	}
+}
+
+// formatFaceSummary returns a line of the face attributes.
+func formatFaceSummary(num int, f face.Face) string {
+	smile := i18n.Message("no smile")
+	if f.Smile.Value >= f.Smile.Threshold {
+		smile = i18n.Message("smiling")
+	}
+
+	return fmt.Sprintf("#%d\t%s, %s\t%s (%.1f), %s\t%s: %.1f / %.1f\t%s: %.0f / %.0f / %.0f",
+		num,
+		i18n.Message(strings.ToLower(f.Gender.Value)),
+		i18n.Message("%d years old", f.Age.Value),
+		smile, f.Smile.Value,
+		i18n.Message(getTopEmotion(f.Emotion)),
+		i18n.Message("beauty(F/M)"), f.Beauty.FemaleScore, f.Beauty.MaleScore,
+		i18n.Message("headpose(yaw/pitch/roll)"), f.YawAngle, f.PitchAngle, f.RollAngle,
+	)
+}
+
+// getTopEmotion returns the emotion which has the highest confidence.
+func getTopEmotion(e face.Emotion) string {
+	list := []struct {
+		name  string
+		value float64
+	}{
+		{"neutral", e.Neutral},
+		{"happiness", e.Happiness},
+		{"surprise", e.Surprise},
+		{"sadness", e.Sadness},
+		{"anger", e.Anger},
+		{"disgust", e.Disgust},
+		{"fear", e.Fear},
+	}
+
+	top := list[0]
+	for _, v := range list[1:] {
+		if v.value > top.value {
+			top = v
+		}
+	}
+	return top.name
+}
+
+// faceDetection is the last result of face command, which is used by follow-up commands.
+type faceDetection struct {
+	Image *faceImage
+	Faces []face.Face
+}
+
+// getFace returns the face of the number. (1-origin)
+func (r faceDetection) getFace(num int) (face.Face, bool) {
+	if num < 1 || num > len(r.Faces) {
+		return face.Face{}, false
+	}
+	return r.Faces[num-1], true
+}
+
+var lastDetectionMu sync.RWMutex
+var lastDetections = make(map[string]faceDetection) // key=channel
+
+func saveLastDetection(channel string, r faceDetection) {
+	lastDetectionMu.Lock()
+	defer lastDetectionMu.Unlock()
+	lastDetections[channel] = r
+}
+
+func getLastDetection(channel string) (faceDetection, bool) {
+	lastDetectionMu.RLock()
+	defer lastDetectionMu.RUnlock()
+	r, ok := lastDetections[channel]
+	return r, ok
+}
@@ -0,0 +1,282 @@
+package faceplusplus
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/eure/bobo/command"
+	"github.com/eure/bobo/library"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+	"github.com/evalphobia/bobo-experiment/slackapi"
+)
+
+// limits of images for Face++ API.
+const (
+	maxImageSize   = 2 * 1024 * 1024 // 2MB
+	maxImagePixels = 4096
+	minImagePixels = 48
+
+	downloadTimeout = 30 * time.Second
+)
+
+// content types which Face++ API supports.
+var supportedImageTypes = map[string]struct{}{
+	"image/jpeg": {},
+	"image/png":  {},
+}
+
+// faceImage is an image from URL, Slack file or an attached file.
+type faceImage struct {
+	URL  string
+	Name string
+	// Data is set for attached files, or after downloading.
+	Data []byte
+}
+
+// getData returns binary of the image.
+func (img *faceImage) getData() ([]byte, error) {
+	if img.Data != nil {
+		return img.Data, nil
+	}
+
+	var byt []byte
+	var err error
+	if fileID, ok := slackapi.ParseFileURL(img.URL); ok {
+		byt, err = downloadSlackFile(fileID)
+	} else {
+		byt, err = downloadURL(img.URL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	img.Data = byt
+	return img.Data, nil
+}
+
+// getBase64 returns base64 encoded image for Face++ API.
+func (img *faceImage) getBase64() (string, error) {
+	byt, err := img.getData()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(byt), nil
+}
+
+// getFaceImages returns images from URLs in the text and attached image files.
+func getFaceImages(d command.CommandData) ([]*faceImage, error) {
+	var list []*faceImage
+	for _, s := range strings.Fields(d.TextOther) {
+		url := library.TrimSigns(s)
+		if !strings.HasPrefix(url, "http") {
+			continue
+		}
+		list = append(list, &faceImage{
+			URL:  url,
+			Name: url,
+		})
+	}
+
+	files, err := slackapi.GetMessageFiles(d.Channel, d.ThreadTimestamp)
+	if err != nil {
+		// attached files are optional when URLs are given.
+		if len(list) != 0 {
+			return list, nil
+		}
+		return nil, err
+	}
+	for _, f := range files {
+		if !f.IsImage() {
+			continue
+		}
+		byt, err := downloadAttachedFile(f)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, &faceImage{
+			URL:  f.URL,
+			Name: f.Name,
+			Data: byt,
+		})
+	}
+
+	if len(list) == 0 {
+		return nil, errors.New(i18n.Message("Set an image URL or attach an image"))
+	}
+	return list, nil
+}
+
+// downloadSlackFile downloads the file with the bot token.
+func downloadSlackFile(fileID string) ([]byte, error) {
+	f, err := slackapi.GetFile(fileID)
+	if err != nil {
+		return nil, err
+	}
+	return downloadAttachedFile(f)
+}
+
+// downloadAttachedFile checks metadata before downloading.
+func downloadAttachedFile(f slackapi.File) ([]byte, error) {
+	if _, ok := supportedImageTypes[f.Mimetype]; !ok {
+		return nil, errors.New(i18n.Message("Unsupported image type [%s]. Use JPEG or PNG", f.Mimetype))
+	}
+	if f.Size > maxImageSize {
+		return nil, errors.New(i18n.Message("Image is too large. It must be less than %dMB", maxImageSize/1024/1024))
+	}
+
+	byt, err := slackapi.DownloadFile(f.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateImage(byt); err != nil {
+		return nil, err
+	}
+	return byt, nil
+}
+
+// downloadClient downloads images from URLs of users.
+// It connects only to public addresses, not to fetch internal resources. (SSRF)
+var downloadClient = &http.Client{
+	Timeout: downloadTimeout,
+	Transport: &http.Transport{
+		// a proxy would be checked instead of the destination.
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: downloadTimeout,
+			Control: checkPublicAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: downloadTimeout,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("stopped after 5 redirects")
+		}
+		return checkDownloadURL(req.URL)
+	},
+}
+
+// non-public networks which net.IP does not have methods for.
+var nonPublicNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"), // shared address space (CGNAT)
+	mustParseCIDR("192.0.0.0/24"),
+	mustParseCIDR("198.18.0.0/15"), // benchmarking
+	mustParseCIDR("240.0.0.0/4"),
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// checkDownloadURL allows only http and https.
+func checkDownloadURL(u *url.URL) error {
+	switch u.Scheme {
+	case "http", "https":
+		return nil
+	}
+	return fmt.Errorf("unsupported scheme: [%s]", u.Scheme)
+}
+
+// checkPublicAddress rejects connections to private, loopback and link-local addresses.
+// It's called after DNS resolution, so hosts which resolve to internal addresses are rejected too.
+func checkPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("forbidden address: [%s]", host)
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	switch {
+	case ip.IsLoopback(),
+		ip.IsPrivate(),
+		ip.IsLinkLocalUnicast(),
+		ip.IsLinkLocalMulticast(),
+		ip.IsInterfaceLocalMulticast(),
+		ip.IsMulticast(),
+		ip.IsUnspecified():
+		return false
+	}
+	for _, n := range nonPublicNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// downloadURL downloads the image and stops reading when it's too large.
+func downloadURL(rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDownloadURL(u); err != nil {
+		return nil, err
+	}
+
+	resp, err := downloadClient.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, errors.New(resp.Status)
+	}
+	if resp.ContentLength > maxImageSize {
+		return nil, errors.New(i18n.Message("Image is too large. It must be less than %dMB", maxImageSize/1024/1024))
+	}
+
+	byt, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if err := validateImage(byt); err != nil {
+		return nil, err
+	}
+	return byt, nil
+}
+
+// validateImage checks the image meets the limits of Face++ API.
+func validateImage(byt []byte) error {
+	if len(byt) > maxImageSize {
+		return errors.New(i18n.Message("Image is too large. It must be less than %dMB", maxImageSize/1024/1024))
+	}
+
+	typ := http.DetectContentType(byt)
+	if _, ok := supportedImageTypes[typ]; !ok {
+		return errors.New(i18n.Message("Unsupported image type [%s]. Use JPEG or PNG", typ))
+	}
+
+	conf, _, err := image.DecodeConfig(bytes.NewReader(byt))
+	if err != nil {
+		return err
+	}
+	switch {
+	case conf.Width > maxImagePixels, conf.Height > maxImagePixels,
+		conf.Width < minImagePixels, conf.Height < minImagePixels:
+		return errors.New(i18n.Message("Image size must be between %dx%d and %dx%d pixels", minImagePixels, minImagePixels, maxImagePixels, maxImagePixels))
+	}
+	return nil
+}
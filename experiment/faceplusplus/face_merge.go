@@ -0,0 +1,299 @@
+package faceplusplus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eure/bobo/command"
+	"github.com/eure/bobo/library"
+	"github.com/evalphobia/go-face-plusplus/beautify"
+	"github.com/evalphobia/go-face-plusplus/config"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+// pending merge requests expire after this duration.
+const mergeSelectionTTL = 10 * time.Minute
+
+// mergeArgs is options of merge commands.
+// e.g.) "url1 url2 80 --face1 2 --face2 1"
+type mergeArgs struct {
+	MergeRate int
+	// face numbers of images. (key=image number, value=face number)
+	Faces map[int]int
+}
+
+// parseMergeArgs parses options from the text, which has image URLs, merge rate and face options.
+// It returns false when the text has an invalid argument.
+func parseMergeArgs(text string) (mergeArgs, bool) {
+	args := mergeArgs{
+		Faces: make(map[int]int),
+	}
+
+	fields := strings.Fields(text)
+	for i := 0; i < len(fields); i++ {
+		// some clients replace "--" with an em dash.
+		s := strings.Replace(fields[i], "—", "--", 1)
+		switch {
+		case strings.HasPrefix(library.TrimSigns(s), "http"):
+			continue
+		case strings.HasPrefix(s, "--face"):
+			// "--face" is the same as "--face1".
+			imageNum := 1
+			if v := strings.TrimPrefix(s, "--face"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil {
+					return args, false
+				}
+				imageNum = n
+			}
+			if i+1 >= len(fields) {
+				return args, false
+			}
+			i++
+			faceNum, err := strconv.Atoi(fields[i])
+			if err != nil || faceNum < 1 {
+				return args, false
+			}
+			args.Faces[imageNum] = faceNum
+			continue
+		}
+
+		rate, err := strconv.Atoi(s)
+		if err != nil {
+			return args, false
+		}
+		args.MergeRate = rate
+	}
+	return args, true
+}
+
+// mergeInput is an image of merge and its face.
+type mergeInput struct {
+	faceDetection
+	// Number is the image number for users. (e.g. 1 for --face1)
+	Number int
+	// FaceNum is a selected face. (1-origin, 0=not selected)
+	FaceNum int
+	// AutoSelect lets Face++ choose the largest face without detection.
+	AutoSelect bool
+}
+
+// getRectangle returns the selected face rectangle.
+func (in mergeInput) getRectangle() (x, y, width, height int) {
+	f, ok := in.getFace(in.FaceNum)
+	if !ok {
+		return 0, 0, 0, 0
+	}
+	return f.Left, f.Top, f.Width, f.Height
+}
+
+// mergeRequest has images and faces to merge.
+type mergeRequest struct {
+	Template  *mergeInput
+	Merge     *mergeInput
+	MergeRate int
+}
+
+func newMergeInput(img *faceImage, imageNum int, args mergeArgs) *mergeInput {
+	return &mergeInput{
+		faceDetection: faceDetection{Image: img},
+		Number:        imageNum,
+		FaceNum:       args.Faces[imageNum],
+	}
+}
+
+// runMergeRequest detects faces of images and merges them.
+// It asks the user to choose a face when an image has multiple faces.
+func runMergeRequest(d command.CommandData, req *mergeRequest) {
+	for _, in := range []*mergeInput{req.Template, req.Merge} {
+		if in.AutoSelect {
+			continue
+		}
+
+		if in.Faces == nil {
+			faces, err := detectFaces(d.SenderID, in.Image)
+			if err != nil {
+				_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [detectFaces] `%s`", err.Error())).Run()
+				return
+			}
+			in.Faces = faces
+		}
+
+		switch {
+		case len(in.Faces) == 0:
+			_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("No face is detected in image %d", in.Number)).Run()
+			return
+		case in.FaceNum == 0 && len(in.Faces) == 1:
+			in.FaceNum = 1
+		case in.FaceNum == 0:
+			askFaceSelection(d, req, in)
+			return
+		case in.FaceNum > len(in.Faces):
+			_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Face #%d is not found in image %d. It has %d faces", in.FaceNum, in.Number, len(in.Faces))).Run()
+			return
+		}
+	}
+	deletePendingMerge(d)
+
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Merging...")).Run()
+
+	resp, err := mergeFaceImage(d.SenderID, req)
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [mergeFaceImage] `%s`", err.Error())).Run()
+		return
+	}
+
+	buf := bytes.NewBuffer(resp)
+	err = command.NewUploadEngineTask(d.Engine, d.Channel, buf, "result.jpg").Run()
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [NewUploadEngineTask] `%s`", err.Error())).Run()
+		return
+	}
+}
+
+// askFaceSelection shows numbered faces and waits for merge:face command.
+func askFaceSelection(d command.CommandData, req *mergeRequest, in *mergeInput) {
+	saveLastDetection(d.Channel, in.faceDetection)
+	savePendingMerge(d, req)
+
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Found %d faces in image %d. Choose one by `merge:face <number>`", len(in.Faces), in.Number)).Run()
+
+	data, err := annotateFaces(in.Image.Data, in.Faces)
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [annotateFaces] `%s`", err.Error())).Run()
+		return
+	}
+	err = command.NewUploadEngineTask(d.Engine, d.Channel, bytes.NewBuffer(data), "faces.jpg").Run()
+	if err != nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, fmt.Sprintf("[ERROR] [NewUploadEngineTask] `%s`", err.Error())).Run()
+		return
+	}
+}
+
+// selectFace sets the face number to the image which waits for selection.
+func (r *mergeRequest) selectFace(num int) {
+	for _, in := range []*mergeInput{r.Template, r.Merge} {
+		if !in.AutoSelect && in.FaceNum == 0 && len(in.Faces) > 1 {
+			in.FaceNum = num
+			return
+		}
+	}
+}
+
+// execute merge face API.
+func mergeFaceImage(userID string, req *mergeRequest) ([]byte, error) {
+	templateBase64, err := req.Template.Image.getBase64()
+	if err != nil {
+		return nil, err
+	}
+	mergeBase64, err := req.Merge.Image.getBase64()
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := req.getCacheKey()
+	if byt, ok := getResultCache().get(cacheKey, 0); ok {
+		getUsageCounter().hit(userID, apiNameMergeFace)
+		return byt, nil
+	}
+	if err := getUsageCounter().use(userID, apiNameMergeFace, true); err != nil {
+		return nil, err
+	}
+
+	svc, err := beautify.New(config.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	r := beautify.MergeFaceRequest{
+		TemplateBase64: templateBase64,
+		MergeBase64:    mergeBase64,
+		MergeRate:      req.MergeRate,
+	}
+	r.TemplateRectangleX, r.TemplateRectangleY, r.TemplateRectangleWidth, r.TemplateRectangleHeight = req.Template.getRectangle()
+	r.MergeRectangleX, r.MergeRectangleY, r.MergeRectangleWidth, r.MergeRectangleHeight = req.Merge.getRectangle()
+
+	resp, err := svc.MergeFace(r)
+	switch {
+	case err != nil:
+		return nil, err
+	case resp.ErrorMessage != "":
+		return nil, errors.New(resp.ErrorMessage)
+	}
+
+	byt, err := resp.GetResultImage()
+	if err != nil {
+		return nil, err
+	}
+	if err := getResultCache().set(cacheKey, byt); err != nil {
+		logger.Errorf(logPrefixFaceplus, "failed to save cache: %s", err.Error())
+	}
+	return byt, nil
+}
+
+// getCacheKey returns a key from the images, selected faces and merge rate.
+// Images must be downloaded before.
+func (r *mergeRequest) getCacheKey() string {
+	tx, ty, tw, th := r.Template.getRectangle()
+	mx, my, mw, mh := r.Merge.getRectangle()
+	key := fmt.Sprintf("%s|%d,%d,%d,%d|%s|%d,%d,%d,%d|%d",
+		hashBytes(r.Template.Image.Data), tx, ty, tw, th,
+		hashBytes(r.Merge.Image.Data), mx, my, mw, mh,
+		r.MergeRate,
+	)
+	return "merge-" + hashBytes([]byte(key))
+}
+
+type pendingMerge struct {
+	req      *mergeRequest
+	expireAt time.Time
+}
+
+var pendingMergeMu sync.Mutex
+var pendingMerges = make(map[string]pendingMerge) // key=channel|user
+
+func pendingMergeKey(d command.CommandData) string {
+	return d.Channel + "|" + d.SenderID
+}
+
+func savePendingMerge(d command.CommandData, req *mergeRequest) {
+	pendingMergeMu.Lock()
+	defer pendingMergeMu.Unlock()
+	pendingMerges[pendingMergeKey(d)] = pendingMerge{
+		req:      req,
+		expireAt: time.Now().Add(mergeSelectionTTL),
+	}
+}
+
+// popPendingMerge returns the pending request and removes it.
+func popPendingMerge(d command.CommandData) (*mergeRequest, bool) {
+	pendingMergeMu.Lock()
+	defer pendingMergeMu.Unlock()
+
+	now := time.Now()
+	for key, p := range pendingMerges {
+		if now.After(p.expireAt) {
+			delete(pendingMerges, key)
+		}
+	}
+
+	key := pendingMergeKey(d)
+	p, ok := pendingMerges[key]
+	if !ok {
+		return nil, false
+	}
+	delete(pendingMerges, key)
+	return p.req, true
+}
+
+func deletePendingMerge(d command.CommandData) {
+	pendingMergeMu.Lock()
+	defer pendingMergeMu.Unlock()
+	delete(pendingMerges, pendingMergeKey(d))
+}
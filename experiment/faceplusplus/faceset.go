@@ -0,0 +1,177 @@
+package faceplusplus
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/evalphobia/bobo-experiment/storage"
+)
+
+const (
+	defaultFaceSetOuterID   = "bobo"
+	defaultFaceSetIndexFile = "faceset_index.json"
+	// older faces are removed when a user registers more.
+	maxFacesPerUser = 5
+)
+
+// FaceSet is a Face++ FaceSet of team members and its local index.
+// It's shared with face:register, face:who and face:forget commands.
+type FaceSet struct {
+	// OuterID is an id of FaceSet in Face++. (default: bobo)
+	OuterID string
+	// IndexFile is a file path to save face tokens of users.
+	// If it's empty, envvar FACEPP_FACESET_INDEX_FILE is used.
+	IndexFile string
+	// Threshold is a minimum confidence to identify users.
+	// If it's zero, the threshold of 1e-4 false acceptance rate from Face++ is used.
+	Threshold float64
+
+	initOnce sync.Once
+	initErr  error
+	store    *storage.JSONFile
+	mu       sync.Mutex
+	index    faceSetIndex
+}
+
+// faceSetIndex is persisted in the index file.
+type faceSetIndex struct {
+	Users map[string]*faceSetUser `json:"users"` // key=UserID
+}
+
+type faceSetUser struct {
+	// FaceTokens are ordered by registered time.
+	FaceTokens  []string  `json:"face_tokens"`
+	ConsentedAt time.Time `json:"consented_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (s *FaceSet) init() error {
+	s.initOnce.Do(func() {
+		s.store = storage.NewJSONFile(s.getIndexFile())
+		s.initErr = s.store.Load(&s.index)
+		if s.index.Users == nil {
+			s.index.Users = make(map[string]*faceSetUser)
+		}
+	})
+	return s.initErr
+}
+
+// hasConsent checks the user has agreed to register.
+func (s *FaceSet) hasConsent(userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.index.Users[userID]
+	return ok
+}
+
+// register adds the face to FaceSet and saves the user of the face.
+func (s *FaceSet) register(userID, faceToken string) (faceCount int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := addFacesToFaceSet(userID, s.getOuterID(), []string{faceToken}); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	prev, ok := s.index.Users[userID]
+	u := &faceSetUser{ConsentedAt: now}
+	if ok {
+		*u = *prev
+	}
+	u.FaceTokens = append(append([]string{}, u.FaceTokens...), faceToken)
+	u.UpdatedAt = now
+	s.index.Users[userID] = u
+
+	if len(u.FaceTokens) > maxFacesPerUser {
+		old := u.FaceTokens[:len(u.FaceTokens)-maxFacesPerUser]
+		if err := removeFacesFromFaceSet(userID, s.getOuterID(), old); err != nil {
+			s.rollbackRegister(userID, faceToken, prev)
+			return 0, err
+		}
+		u.FaceTokens = append([]string{}, u.FaceTokens[len(old):]...)
+	}
+
+	if err := s.store.Save(s.index); err != nil {
+		// the face cannot be forgotten when it's not in the index file.
+		s.rollbackRegister(userID, faceToken, prev)
+		return 0, err
+	}
+	return len(u.FaceTokens), nil
+}
+
+// rollbackRegister removes the added face from FaceSet and restores the user in the index.
+func (s *FaceSet) rollbackRegister(userID, faceToken string, prev *faceSetUser) {
+	if err := removeFacesFromFaceSet(userID, s.getOuterID(), []string{faceToken}); err != nil {
+		logger.Errorf(logPrefixFaceplus, "failed to remove the face from FaceSet: user=[%s] error=[%s]", userID, err.Error())
+	}
+	if prev == nil {
+		delete(s.index.Users, userID)
+		return
+	}
+	s.index.Users[userID] = prev
+}
+
+// forget removes all of the faces and the consent of the user.
+func (s *FaceSet) forget(userID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.index.Users[userID]
+	if !ok {
+		return false, nil
+	}
+	if err := removeFacesFromFaceSet(userID, s.getOuterID(), u.FaceTokens); err != nil {
+		return false, err
+	}
+	forgetFaceTokens(u.FaceTokens)
+	delete(s.index.Users, userID)
+	return true, s.store.Save(s.index)
+}
+
+// identify returns the user of the face, or empty string when the user is not found.
+// senderID is used for quotas.
+func (s *FaceSet) identify(senderID, faceToken string) (userID string, confidence float64, err error) {
+	resultToken, confidence, threshold, err := searchFace(senderID, s.getOuterID(), faceToken)
+	switch {
+	case err != nil:
+		return "", 0, err
+	case resultToken == "":
+		return "", 0, nil
+	}
+	if s.Threshold > 0 {
+		threshold = s.Threshold
+	}
+	if confidence < threshold {
+		return "", confidence, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, u := range s.index.Users {
+		for _, token := range u.FaceTokens {
+			if token == resultToken {
+				return id, confidence, nil
+			}
+		}
+	}
+	return "", confidence, nil
+}
+
+func (s *FaceSet) getOuterID() string {
+	if s.OuterID != "" {
+		return s.OuterID
+	}
+	return defaultFaceSetOuterID
+}
+
+func (s *FaceSet) getIndexFile() string {
+	switch {
+	case s.IndexFile != "":
+		return s.IndexFile
+	case os.Getenv("FACEPP_FACESET_INDEX_FILE") != "":
+		return os.Getenv("FACEPP_FACESET_INDEX_FILE")
+	}
+	return defaultFaceSetIndexFile
+}
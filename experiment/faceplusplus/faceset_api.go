@@ -0,0 +1,163 @@
+package faceplusplus
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/evalphobia/go-face-plusplus/client"
+	"github.com/evalphobia/go-face-plusplus/config"
+)
+
+// go-face-plusplus does not have FaceSet and Search APIs, so they are called by its client.
+const (
+	apiPathFaceSetCreate     = "/facepp/v3/faceset/create"
+	apiPathFaceSetRemoveFace = "/facepp/v3/faceset/removeface"
+	apiPathSearch            = "/facepp/v3/search"
+
+	// max face tokens in a request of FaceSet API.
+	maxFaceTokensPerRequest = 5
+)
+
+type faceSetCreateRequest struct {
+	OuterID     string `url:"outer_id"`
+	DisplayName string `url:"display_name,omitempty"`
+	FaceTokens  string `url:"face_tokens,omitempty"`
+	// ForceMerge adds face tokens to the existing FaceSet of outer_id.
+	ForceMerge int `url:"force_merge"`
+}
+
+type faceSetResponse struct {
+	client.BaseResponse
+	FaceSetToken  string `json:"faceset_token"`
+	OuterID       string `json:"outer_id"`
+	FaceAdded     int    `json:"face_added"`
+	FaceRemoved   int    `json:"face_removed"`
+	FaceCount     int    `json:"face_count"`
+	FailureDetail []struct {
+		FaceToken string `json:"face_token"`
+		Reason    string `json:"reason"`
+	} `json:"failure_detail"`
+}
+
+type faceSetRemoveRequest struct {
+	OuterID    string `url:"outer_id"`
+	FaceTokens string `url:"face_tokens"`
+}
+
+type searchRequest struct {
+	FaceToken         string `url:"face_token"`
+	OuterID           string `url:"outer_id"`
+	ReturnResultCount int    `url:"return_result_count"`
+}
+
+type searchResponse struct {
+	client.BaseResponse
+	Results []struct {
+		FaceToken  string  `json:"face_token"`
+		Confidence float64 `json:"confidence"`
+		UserID     string  `json:"user_id"`
+	} `json:"results"`
+	// Thresholds are confidence thresholds of false acceptance rate.
+	Thresholds struct {
+		E3 float64 `json:"1e-3"`
+		E4 float64 `json:"1e-4"`
+		E5 float64 `json:"1e-5"`
+	} `json:"thresholds"`
+}
+
+// addFacesToFaceSet adds face tokens to the FaceSet, and creates the FaceSet when it does not exist.
+func addFacesToFaceSet(userID, outerID string, faceTokens []string) error {
+	cli, err := config.Config{}.Client()
+	if err != nil {
+		return err
+	}
+
+	for len(faceTokens) > 0 {
+		size := maxFaceTokensPerRequest
+		if len(faceTokens) < size {
+			size = len(faceTokens)
+		}
+
+		if err := getUsageCounter().use(userID, apiNameFaceSet, true); err != nil {
+			return err
+		}
+		resp := faceSetResponse{}
+		err := cli.CallPOST(apiPathFaceSetCreate, faceSetCreateRequest{
+			OuterID:     outerID,
+			DisplayName: outerID,
+			FaceTokens:  strings.Join(faceTokens[:size], ","),
+			ForceMerge:  1,
+		}, &resp)
+		switch {
+		case err != nil:
+			return err
+		case resp.ErrorMessage != "":
+			return errors.New(resp.ErrorMessage)
+		case len(resp.FailureDetail) != 0:
+			return errors.New(resp.FailureDetail[0].Reason)
+		}
+		faceTokens = faceTokens[size:]
+	}
+	return nil
+}
+
+// removeFacesFromFaceSet removes face tokens from the FaceSet.
+// It's not limited by quotas, to delete faces anytime.
+func removeFacesFromFaceSet(userID, outerID string, faceTokens []string) error {
+	cli, err := config.Config{}.Client()
+	if err != nil {
+		return err
+	}
+
+	for len(faceTokens) > 0 {
+		size := maxFaceTokensPerRequest
+		if len(faceTokens) < size {
+			size = len(faceTokens)
+		}
+
+		_ = getUsageCounter().use(userID, apiNameFaceSet, false)
+		resp := faceSetResponse{}
+		err := cli.CallPOST(apiPathFaceSetRemoveFace, faceSetRemoveRequest{
+			OuterID:    outerID,
+			FaceTokens: strings.Join(faceTokens[:size], ","),
+		}, &resp)
+		switch {
+		case err != nil:
+			return err
+		case resp.ErrorMessage != "":
+			return errors.New(resp.ErrorMessage)
+		}
+		faceTokens = faceTokens[size:]
+	}
+	return nil
+}
+
+// searchFace searches the most similar face in the FaceSet.
+// It returns empty token when the FaceSet has no face.
+func searchFace(userID, outerID, faceToken string) (resultToken string, confidence, threshold float64, err error) {
+	cli, err := config.Config{}.Client()
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if err := getUsageCounter().use(userID, apiNameSearch, true); err != nil {
+		return "", 0, 0, err
+	}
+
+	resp := searchResponse{}
+	err = cli.CallPOST(apiPathSearch, searchRequest{
+		FaceToken:         faceToken,
+		OuterID:           outerID,
+		ReturnResultCount: 1,
+	}, &resp)
+	switch {
+	case err != nil:
+		return "", 0, 0, err
+	case resp.ErrorMessage != "":
+		return "", 0, 0, errors.New(resp.ErrorMessage)
+	case len(resp.Results) == 0:
+		return "", 0, 0, nil
+	}
+
+	r := resp.Results[0]
+	return r.FaceToken, r.Confidence, resp.Thresholds.E4, nil
+}
@@ -0,0 +1,167 @@
+package faceplusplus
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+	"github.com/evalphobia/bobo-experiment/storage"
+)
+
+const defaultMergeTargetFile = "merge_targets.json"
+
+var mergeTargetNameRegexp = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// MergeTargets is a list of merge targets, which is loaded from the config file and added at runtime.
+// e.g.) {"obama": {"urls": ["https://example.com/obama.jpg"], "merge_from_target": false}}
+type MergeTargets struct {
+	// File is a config file path of merge targets. Targets added at runtime are saved into it.
+	// If it's empty, envvar MERGE_TARGET_FILE is used.
+	File string
+	// Defaults are saved into the config file when the file does not exist.
+	Defaults map[string][]string // key=name, value=image URLs
+
+	initOnce sync.Once
+	initErr  error
+	store    *storage.JSONFile
+	mu       sync.RWMutex
+	targets  map[string]*mergeTarget // key=name
+}
+
+type mergeTarget struct {
+	URLs            []string `json:"urls"`
+	MergeFromTarget bool     `json:"merge_from_target"`
+}
+
+// Init loads the config file, and creates it with Defaults when it does not exist.
+// Call it at startup to list the targets in help, otherwise they are loaded at the first command.
+func (t *MergeTargets) Init() error {
+	return t.init()
+}
+
+func (t *MergeTargets) init() error {
+	t.initOnce.Do(func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		file := t.getFile()
+		_, err := os.Stat(file)
+		isNew := os.IsNotExist(err)
+
+		t.store = storage.NewJSONFile(file)
+		t.initErr = t.store.Load(&t.targets)
+		if t.targets == nil {
+			t.targets = make(map[string]*mergeTarget)
+		}
+		if isNew && len(t.Defaults) != 0 {
+			for name, urls := range t.Defaults {
+				t.targets[name] = &mergeTarget{URLs: urls}
+			}
+			t.initErr = t.store.Save(t.targets)
+		}
+	})
+	return t.initErr
+}
+
+// get returns the target which has valid URLs.
+func (t *MergeTargets) get(name string) (mergeTarget, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	target, ok := t.targets[name]
+	if !ok {
+		return mergeTarget{}, errors.New(i18n.Message("Merge target [%s] is not found", name))
+	}
+
+	// ignore broken urls in the config file.
+	result := mergeTarget{MergeFromTarget: target.MergeFromTarget}
+	for _, u := range target.URLs {
+		if validateTargetURL(u) == nil {
+			result.URLs = append(result.URLs, u)
+		}
+	}
+	if len(result.URLs) == 0 {
+		return mergeTarget{}, errors.New(i18n.Message("Merge target [%s] has no valid image", name))
+	}
+	return result, nil
+}
+
+// names returns sorted names of targets.
+// It returns nothing before the targets are loaded.
+func (t *MergeTargets) names() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	list := make([]string, 0, len(t.targets))
+	for name := range t.targets {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// add adds the image url to the target and saves it into the config file.
+func (t *MergeTargets) add(name, imageURL string) (count int, err error) {
+	if !mergeTargetNameRegexp.MatchString(name) {
+		return 0, errors.New(i18n.Message("Invalid target name. Use [a-z0-9_]"))
+	}
+	if err := validateTargetURL(imageURL); err != nil {
+		return 0, err
+	}
+	// check the image can be used for Face++.
+	img := &faceImage{URL: imageURL}
+	if _, err := img.getData(); err != nil {
+		return 0, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	target, ok := t.targets[name]
+	if !ok {
+		target = &mergeTarget{}
+		t.targets[name] = target
+	}
+	for _, u := range target.URLs {
+		if u == imageURL {
+			return len(target.URLs), nil
+		}
+	}
+	target.URLs = append(target.URLs, imageURL)
+	return len(target.URLs), t.store.Save(t.targets)
+}
+
+func (t *MergeTargets) getFile() string {
+	switch {
+	case t.File != "":
+		return t.File
+	case os.Getenv("MERGE_TARGET_FILE") != "":
+		return os.Getenv("MERGE_TARGET_FILE")
+	}
+	return defaultMergeTargetFile
+}
+
+// validateTargetURL checks the url is absolute http/https url.
+func validateTargetURL(s string) error {
+	u, err := url.Parse(s)
+	switch {
+	case err != nil,
+		u.Scheme != "http" && u.Scheme != "https",
+		u.Host == "":
+		return errors.New(i18n.Message("Invalid URL. It must begin with [http/https]"))
+	}
+	return nil
+}
+
+// parseMergeTargetName returns target name from the command. e.g.) "merge-obama"
+func parseMergeTargetName(comm string) (string, bool) {
+	if !strings.HasPrefix(comm, "merge-") {
+		return "", false
+	}
+	return strings.ToLower(strings.TrimPrefix(comm, "merge-")), true
+}
@@ -0,0 +1,156 @@
+package faceplusplus
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/eure/bobo/log"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+	"github.com/evalphobia/bobo-experiment/storage"
+)
+
+const defaultUsageFile = "faceplusplus_usage.json"
+
+// api names for usage.
+const (
+	apiNameDetect    = "detect"
+	apiNameMergeFace = "mergeface"
+	apiNameFaceSet   = "faceset"
+	apiNameSearch    = "search"
+)
+
+const logPrefixFaceplus = "Face++"
+
+var logger log.Logger = log.DefaultLogger
+
+// SetLogger sets logger for usage and cache of Face++ calls.
+func SetLogger(l log.Logger) {
+	logger = l
+}
+
+var usageOnce sync.Once
+var faceppUsage *usageCounter
+
+// getUsageCounter returns the counter of Face++ calls.
+// Quotas are set by envvar FACEPP_DAILY_LIMIT and FACEPP_USER_DAILY_LIMIT. (0 means no limit)
+func getUsageCounter() *usageCounter {
+	usageOnce.Do(func() {
+		filePath := os.Getenv("FACEPP_USAGE_FILE")
+		if filePath == "" {
+			filePath = defaultUsageFile
+		}
+		faceppUsage = &usageCounter{
+			DailyLimit:     getEnvInt("FACEPP_DAILY_LIMIT", 0),
+			UserDailyLimit: getEnvInt("FACEPP_USER_DAILY_LIMIT", 0),
+			store:          storage.NewJSONFile(filePath),
+		}
+	})
+	return faceppUsage
+}
+
+// usageCounter counts Face++ calls per day and enforces quotas.
+type usageCounter struct {
+	DailyLimit     int
+	UserDailyLimit int
+
+	store  *storage.JSONFile
+	mu     sync.Mutex
+	loaded bool
+	state  usageState
+}
+
+// usageState is persisted in the usage file.
+type usageState struct {
+	Date      string         `json:"date"` // YYYY-MM-DD
+	Total     int            `json:"total"`
+	CacheHits int            `json:"cache_hits"`
+	APIs      map[string]int `json:"apis"`  // key=api name
+	Users     map[string]int `json:"users"` // key=UserID
+}
+
+// use checks quotas and counts the call.
+// Calls for deletion should set enforce=false, not to be blocked by quotas.
+func (u *usageCounter) use(userID, api string, enforce bool) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.prepare()
+
+	total := u.state.Total
+	userTotal := u.state.Users[userID]
+	switch {
+	case !enforce:
+	case u.DailyLimit > 0 && total >= u.DailyLimit:
+		logger.Infof(logPrefixFaceplus, "quota exceeded api=%s user=%s total=%d/%d", api, userID, total, u.DailyLimit)
+		return errors.New(i18n.Message("Daily quota of Face++ is exceeded. (%d/%d)", total, u.DailyLimit))
+	case u.UserDailyLimit > 0 && userTotal >= u.UserDailyLimit:
+		logger.Infof(logPrefixFaceplus, "user quota exceeded api=%s user=%s user_total=%d/%d", api, userID, userTotal, u.UserDailyLimit)
+		return errors.New(i18n.Message("Your daily quota of Face++ is exceeded. (%d/%d)", userTotal, u.UserDailyLimit))
+	}
+
+	u.state.Total++
+	u.state.APIs[api]++
+	u.state.Users[userID]++
+	logger.Infof(logPrefixFaceplus, "call api=%s user=%s total=%d/%d user_total=%d/%d", api, userID, u.state.Total, u.DailyLimit, u.state.Users[userID], u.UserDailyLimit)
+	u.save()
+	return nil
+}
+
+// hit counts the cache hit.
+func (u *usageCounter) hit(userID, api string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.prepare()
+
+	u.state.CacheHits++
+	logger.Infof(logPrefixFaceplus, "cache hit api=%s user=%s hits=%d", api, userID, u.state.CacheHits)
+	u.save()
+}
+
+// getState returns a copy of today's usage.
+func (u *usageCounter) getState() usageState {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.prepare()
+
+	s := u.state
+	s.APIs = make(map[string]int, len(u.state.APIs))
+	for k, v := range u.state.APIs {
+		s.APIs[k] = v
+	}
+	s.Users = make(map[string]int, len(u.state.Users))
+	for k, v := range u.state.Users {
+		s.Users[k] = v
+	}
+	return s
+}
+
+// prepare loads the file and resets counts when the date is changed.
+func (u *usageCounter) prepare() {
+	if !u.loaded {
+		if err := u.store.Load(&u.state); err != nil {
+			logger.Errorf(logPrefixFaceplus, "failed to load usage: %s", err.Error())
+		}
+		u.loaded = true
+	}
+
+	today := time.Now().Format("2006-01-02")
+	if u.state.Date != today {
+		u.state = usageState{Date: today}
+	}
+	if u.state.APIs == nil {
+		u.state.APIs = make(map[string]int)
+	}
+	if u.state.Users == nil {
+		u.state.Users = make(map[string]int)
+	}
+}
+
+// save does not return error, not to block Face++ calls by the file.
+func (u *usageCounter) save() {
+	if err := u.store.Save(u.state); err != nil {
+		logger.Errorf(logPrefixFaceplus, "failed to save usage: %s", err.Error())
+	}
+}
@@ -0,0 +1,199 @@
+package google
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxAgendaDays is a limit of days to show on the calendar command.
+const maxAgendaDays = 31
+
+// agendaRange is a time range of the calendar command.
+// End is exclusive.
+type agendaRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Days returns dates within the range.
+func (r agendaRange) Days() []time.Time {
+	var list []time.Time
+	for dt := truncateDay(r.Start); dt.Before(r.End); dt = dt.AddDate(0, 0, 1) {
+		list = append(list, dt)
+	}
+	return list
+}
+
+// words for a single day.
+var agendaDayWords = map[string]int{
+	"today":    0,
+	"今日":       0,
+	"きょう":      0,
+	"本日":       0,
+	"tomorrow": 1,
+	"明日":       1,
+	"あした":      1,
+	"あす":       1,
+	"明後日":      2,
+	"あさって":     2,
+}
+
+// words for a week. the value is the offset of weeks.
+var agendaWeekWords = map[string]int{
+	"this week": 0,
+	"thisweek":  0,
+	"今週":        0,
+	"next week": 1,
+	"nextweek":  1,
+	"来週":        1,
+}
+
+var agendaWeekdayWords = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday, "日": time.Sunday, "日曜": time.Sunday, "日曜日": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday, "月": time.Monday, "月曜": time.Monday, "月曜日": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday, "火": time.Tuesday, "火曜": time.Tuesday, "火曜日": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday, "水": time.Wednesday, "水曜": time.Wednesday, "水曜日": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday, "木": time.Thursday, "木曜": time.Thursday, "木曜日": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday, "金": time.Friday, "金曜": time.Friday, "金曜日": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday, "土": time.Saturday, "土曜": time.Saturday, "土曜日": time.Saturday,
+}
+
+var (
+	reAgendaSlashDate = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})$`)
+	reAgendaJADate    = regexp.MustCompile(`^(?:(\d{4})年)?(\d{1,2})月(\d{1,2})日$`)
+	reAgendaRange     = regexp.MustCompile(`\.\.|~|〜|～`)
+)
+
+// parseAgendaRange parses date words of the calendar command.
+// e.g.) "", "today", "tomorrow", "thu", "mon..fri", "2024-06-03", "6/3", "next week", "明日", "来週", "6月3日"
+// When text is empty, it returns the range from now to the end of tomorrow.
+func parseAgendaRange(text string, now time.Time) (agendaRange, error) {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	today := truncateDay(now)
+	if text == "" {
+		return agendaRange{Start: now, End: today.AddDate(0, 0, 2)}, nil
+	}
+
+	if offset, ok := agendaWeekWords[text]; ok {
+		start := startOfWeek(today).AddDate(0, 0, 7*offset)
+		return agendaRange{Start: start, End: start.AddDate(0, 0, 7)}, nil
+	}
+
+	// range of days. e.g.) "mon..fri", "2024-06-03..2024-06-07"
+	if parts := reAgendaRange.Split(text, 2); len(parts) == 2 {
+		return parseAgendaDayRange(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), today)
+	}
+
+	day, err := parseAgendaDay(text, today)
+	if err != nil {
+		return agendaRange{}, err
+	}
+	return agendaRange{Start: day, End: day.AddDate(0, 0, 1)}, nil
+}
+
+func parseAgendaDayRange(from, to string, today time.Time) (agendaRange, error) {
+	// weekday range is in the current week, or next week when it's already over.
+	fromWD, ok1 := agendaWeekdayWords[from]
+	toWD, ok2 := agendaWeekdayWords[to]
+	if ok1 && ok2 {
+		monday := startOfWeek(today)
+		start := monday.AddDate(0, 0, weekdayOffset(fromWD))
+		end := monday.AddDate(0, 0, weekdayOffset(toWD))
+		if end.Before(start) {
+			end = end.AddDate(0, 0, 7)
+		}
+		if end.Before(today) {
+			start = start.AddDate(0, 0, 7)
+			end = end.AddDate(0, 0, 7)
+		}
+		return newAgendaRange(start, end)
+	}
+
+	start, err := parseAgendaDay(from, today)
+	if err != nil {
+		return agendaRange{}, err
+	}
+	end, err := parseAgendaDay(to, today)
+	if err != nil {
+		return agendaRange{}, err
+	}
+	return newAgendaRange(start, end)
+}
+
+// newAgendaRange returns range from start to end (inclusive).
+func newAgendaRange(start, end time.Time) (agendaRange, error) {
+	if end.Before(start) {
+		return agendaRange{}, fmt.Errorf("end date is before start date: [%s]", end.Format("2006-01-02"))
+	}
+	r := agendaRange{Start: start, End: end.AddDate(0, 0, 1)}
+	if len(r.Days()) > maxAgendaDays {
+		return agendaRange{}, fmt.Errorf("range is too long, max %d days", maxAgendaDays)
+	}
+	return r, nil
+}
+
+// parseAgendaDay parses a single day.
+func parseAgendaDay(text string, today time.Time) (time.Time, error) {
+	if offset, ok := agendaDayWords[text]; ok {
+		return today.AddDate(0, 0, offset), nil
+	}
+
+	// the next weekday including today.
+	if wd, ok := agendaWeekdayWords[text]; ok {
+		diff := (int(wd) - int(today.Weekday()) + 7) % 7
+		return today.AddDate(0, 0, diff), nil
+	}
+
+	if dt, err := time.ParseInLocation("2006-01-02", text, today.Location()); err == nil {
+		return dt, nil
+	}
+
+	// dates without year are treated as the nearest date.
+	if m := reAgendaSlashDate.FindStringSubmatch(text); len(m) != 0 {
+		return newAgendaDate(today, "", m[1], m[2])
+	}
+	if m := reAgendaJADate.FindStringSubmatch(text); len(m) != 0 {
+		return newAgendaDate(today, m[1], m[2], m[3])
+	}
+	return time.Time{}, fmt.Errorf("invalid date: [%s]", text)
+}
+
+func newAgendaDate(today time.Time, year, month, day string) (time.Time, error) {
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	if m < 1 || m > 12 || d < 1 || d > 31 {
+		return time.Time{}, fmt.Errorf("invalid date: [%s/%s]", month, day)
+	}
+
+	y := today.Year()
+	if year != "" {
+		y, _ = strconv.Atoi(year)
+	}
+	dt := time.Date(y, time.Month(m), d, 0, 0, 0, 0, today.Location())
+	if year == "" && dt.Before(today.AddDate(0, -6, 0)) {
+		dt = dt.AddDate(1, 0, 0)
+	}
+	return dt, nil
+}
+
+func truncateDay(dt time.Time) time.Time {
+	return time.Date(dt.Year(), dt.Month(), dt.Day(), 0, 0, 0, 0, dt.Location())
+}
+
+// atClock returns the wall clock time on the day, which is correct on DST boundaries.
+func atClock(day time.Time, clock time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, int(clock/time.Minute), 0, 0, day.Location())
+}
+
+// startOfWeek returns Monday of the week.
+func startOfWeek(dt time.Time) time.Time {
+	return truncateDay(dt).AddDate(0, 0, -weekdayOffset(dt.Weekday()))
+}
+
+// weekdayOffset returns days from Monday.
+func weekdayOffset(wd time.Weekday) int {
+	return (int(wd) + 6) % 7
+}
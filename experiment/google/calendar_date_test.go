@@ -0,0 +1,172 @@
+package google
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%s) error = %v", name, err)
+	}
+	return loc
+}
+
+func TestAtClock(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	london := mustLoadLocation(t, "Europe/London")
+
+	tests := []struct {
+		name  string
+		day   time.Time
+		clock time.Duration
+		want  string
+	}{
+		{"NY/normal day", time.Date(2024, 6, 3, 15, 0, 0, 0, newYork), 9 * time.Hour, "2024-06-03T09:00:00-04:00"},
+		{"NY/spring-forward/midnight", time.Date(2024, 3, 10, 15, 0, 0, 0, newYork), 0, "2024-03-10T00:00:00-05:00"},
+		{"NY/spring-forward/morning", time.Date(2024, 3, 10, 15, 0, 0, 0, newYork), 9 * time.Hour, "2024-03-10T09:00:00-04:00"},
+		{"NY/spring-forward/night", time.Date(2024, 3, 10, 1, 0, 0, 0, newYork), 23*time.Hour + 30*time.Minute, "2024-03-10T23:30:00-04:00"},
+		{"NY/fall-back/midnight", time.Date(2024, 11, 3, 15, 0, 0, 0, newYork), 0, "2024-11-03T00:00:00-04:00"},
+		{"NY/fall-back/morning", time.Date(2024, 11, 3, 15, 0, 0, 0, newYork), 9 * time.Hour, "2024-11-03T09:00:00-05:00"},
+		{"London/spring-forward/before", time.Date(2024, 3, 31, 15, 0, 0, 0, london), 30 * time.Minute, "2024-03-31T00:30:00Z"},
+		{"London/spring-forward/morning", time.Date(2024, 3, 31, 15, 0, 0, 0, london), 9 * time.Hour, "2024-03-31T09:00:00+01:00"},
+		{"London/fall-back/before", time.Date(2024, 10, 27, 15, 0, 0, 0, london), 30 * time.Minute, "2024-10-27T00:30:00+01:00"},
+		{"London/fall-back/morning", time.Date(2024, 10, 27, 15, 0, 0, 0, london), 9 * time.Hour, "2024-10-27T09:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := atClock(tt.day, tt.clock).Format(time.RFC3339)
+			if got != tt.want {
+				t.Errorf("atClock() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateDay(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	london := mustLoadLocation(t, "Europe/London")
+
+	tests := []struct {
+		name string
+		dt   time.Time
+		want string
+	}{
+		{"NY/spring-forward", time.Date(2024, 3, 10, 15, 0, 0, 0, newYork), "2024-03-10T00:00:00-05:00"},
+		{"NY/fall-back", time.Date(2024, 11, 3, 23, 0, 0, 0, newYork), "2024-11-03T00:00:00-04:00"},
+		{"NY/after fall-back", time.Date(2024, 11, 4, 0, 30, 0, 0, newYork), "2024-11-04T00:00:00-05:00"},
+		{"London/spring-forward", time.Date(2024, 3, 31, 12, 0, 0, 0, london), "2024-03-31T00:00:00Z"},
+		{"London/fall-back", time.Date(2024, 10, 27, 12, 0, 0, 0, london), "2024-10-27T00:00:00+01:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateDay(tt.dt).Format(time.RFC3339)
+			if got != tt.want {
+				t.Errorf("truncateDay() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgendaRangeDays(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	london := mustLoadLocation(t, "Europe/London")
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		want  []string
+	}{
+		{
+			name:  "NY/spring-forward",
+			start: time.Date(2024, 3, 9, 0, 0, 0, 0, newYork),
+			end:   time.Date(2024, 3, 11, 0, 0, 0, 0, newYork),
+			want:  []string{"2024-03-09T00:00:00-05:00", "2024-03-10T00:00:00-05:00", "2024-03-11T00:00:00-04:00"},
+		},
+		{
+			name:  "London/fall-back",
+			start: time.Date(2024, 10, 26, 0, 0, 0, 0, london),
+			end:   time.Date(2024, 10, 28, 0, 0, 0, 0, london),
+			want:  []string{"2024-10-26T00:00:00+01:00", "2024-10-27T00:00:00+01:00", "2024-10-28T00:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newAgendaRange(tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("newAgendaRange() error = %v", err)
+			}
+			days := r.Days()
+			if len(days) != len(tt.want) {
+				t.Fatalf("Days() = %v, want %v", days, tt.want)
+			}
+			for i, dt := range days {
+				if got := dt.Format(time.RFC3339); got != tt.want[i] {
+					t.Errorf("Days()[%d] = %s, want %s", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseAgendaRange(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	london := mustLoadLocation(t, "Europe/London")
+	// Sunday of spring-forward and fall-back.
+	nyNow := time.Date(2024, 3, 10, 10, 0, 0, 0, newYork)
+	londonNow := time.Date(2024, 10, 27, 10, 0, 0, 0, london)
+
+	tests := []struct {
+		name    string
+		text    string
+		now     time.Time
+		want    string
+		wantErr bool
+	}{
+		{"NY/empty", "", nyNow, "2024-03-10T10:00:00-04:00..2024-03-12T00:00:00-04:00", false},
+		{"NY/today", "today", nyNow, "2024-03-10T00:00:00-05:00..2024-03-11T00:00:00-04:00", false},
+		{"NY/tomorrow", "Tomorrow", nyNow, "2024-03-11T00:00:00-04:00..2024-03-12T00:00:00-04:00", false},
+		{"NY/tomorrow ja", "明日", nyNow, "2024-03-11T00:00:00-04:00..2024-03-12T00:00:00-04:00", false},
+		{"NY/this week", "this  week", nyNow, "2024-03-04T00:00:00-05:00..2024-03-11T00:00:00-04:00", false},
+		{"NY/next week", "来週", nyNow, "2024-03-11T00:00:00-04:00..2024-03-18T00:00:00-04:00", false},
+		{"NY/weekday", "tue", nyNow, "2024-03-12T00:00:00-04:00..2024-03-13T00:00:00-04:00", false},
+		{"NY/weekday range already over", "mon..fri", nyNow, "2024-03-11T00:00:00-04:00..2024-03-16T00:00:00-04:00", false},
+		{"NY/date range", "2024-03-09..2024-03-11", nyNow, "2024-03-09T00:00:00-05:00..2024-03-12T00:00:00-04:00", false},
+		{"NY/slash date", "3/12", nyNow, "2024-03-12T00:00:00-04:00..2024-03-13T00:00:00-04:00", false},
+		{"NY/ja date", "3月12日", nyNow, "2024-03-12T00:00:00-04:00..2024-03-13T00:00:00-04:00", false},
+		{"London/empty", "", londonNow, "2024-10-27T10:00:00Z..2024-10-29T00:00:00Z", false},
+		{"London/today", "today", londonNow, "2024-10-27T00:00:00+01:00..2024-10-28T00:00:00Z", false},
+		{"London/date range", "2024-10-26〜2024-10-28", londonNow, "2024-10-26T00:00:00+01:00..2024-10-29T00:00:00Z", false},
+		{"London/next year", "1/2", time.Date(2024, 12, 30, 10, 0, 0, 0, london), "2025-01-02T00:00:00Z..2025-01-03T00:00:00Z", false},
+		{"reversed range", "2024-03-11..2024-03-09", nyNow, "", true},
+		{"too long range", "2024-03-01..2024-04-30", nyNow, "", true},
+		{"invalid date", "13/40", nyNow, "", true},
+		{"unknown word", "someday", nyNow, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseAgendaRange(tt.text, tt.now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseAgendaRange() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAgendaRange() error = %v", err)
+			}
+			got := r.Start.Format(time.RFC3339) + ".." + r.End.Format(time.RFC3339)
+			if got != tt.want {
+				t.Errorf("parseAgendaRange() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
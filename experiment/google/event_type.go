@@ -0,0 +1,118 @@
+package google
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/evalphobia/google-api-go-wrapper/calendar"
+	SDK "google.golang.org/api/calendar/v3"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+// event types of Google Calendar.
+const (
+	eventTypeDefault         = "default"
+	eventTypeOutOfOffice     = "outOfOffice"
+	eventTypeFocusTime       = "focusTime"
+	eventTypeWorkingLocation = "workingLocation"
+)
+
+// calendarEvent is an event with its type, which google-api-go-wrapper does not have.
+type calendarEvent struct {
+	calendar.Event
+	EventType string
+	// WorkingLocation is a label of working location. (only for workingLocation event)
+	WorkingLocation string
+	IsHomeOffice    bool
+}
+
+func newCalendarEvent(e *SDK.Event) calendarEvent {
+	ev := calendarEvent{
+		Event:     calendar.NewEvent(e),
+		EventType: e.EventType,
+	}
+	if ev.EventType == "" {
+		ev.EventType = eventTypeDefault
+	}
+
+	if p := e.WorkingLocationProperties; p != nil {
+		switch {
+		case p.HomeOffice != nil:
+			ev.IsHomeOffice = true
+		case p.OfficeLocation != nil:
+			ev.WorkingLocation = p.OfficeLocation.Label
+			if ev.WorkingLocation == "" {
+				ev.WorkingLocation = p.OfficeLocation.BuildingId
+			}
+		case p.CustomLocation != nil:
+			ev.WorkingLocation = p.CustomLocation.Label
+		}
+	}
+	return ev
+}
+
+// getWorkingLocationName returns friendly name of the working location.
+func (e calendarEvent) getWorkingLocationName() string {
+	if e.IsHomeOffice {
+		return i18n.Message("home")
+	}
+	if names, ok := workingLocationNames[e.WorkingLocation]; ok {
+		if name := names[i18n.Language()]; name != "" {
+			return name
+		}
+	}
+	return e.WorkingLocation
+}
+
+// fetchCurrentEvents fetches events around now.
+func fetchCurrentEvents(svc *SDK.Service, email string) ([]calendarEvent, error) {
+	now := time.Now()
+	resp, err := svc.Events.List(email).
+		TimeMin(now.Add(-1*time.Hour).Format(time.RFC3339)).
+		TimeMax(now.Add(24*time.Hour).Format(time.RFC3339)).
+		EventTypes(eventTypeDefault, eventTypeOutOfOffice, eventTypeFocusTime, eventTypeWorkingLocation).
+		SingleEvents(true).
+		OrderBy("startTime").
+		MaxResults(20).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]calendarEvent, len(resp.Items))
+	for i, e := range resp.Items {
+		results[i] = newCalendarEvent(e)
+	}
+	return results, nil
+}
+
+var workingLocationOnce sync.Once
+var workingLocationErr error
+var workingLocationNames map[string]map[string]string // key=label, key of value=language
+
+// loadWorkingLocationNames loads friendly names of working location labels from the JSON file.
+// e.g.) {"osaka-3f": {"en": "Osaka office", "ja": "大阪オフィス"}}
+func loadWorkingLocationNames() error {
+	workingLocationOnce.Do(func() {
+		filePath := os.Getenv("WORKING_LOCATION_FILE")
+		if filePath == "" {
+			return
+		}
+
+		byt, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			workingLocationErr = err
+			return
+		}
+
+		if err := json.Unmarshal(byt, &workingLocationNames); err != nil {
+			workingLocationErr = err
+			return
+		}
+	})
+	return workingLocationErr
+}
@@ -5,11 +5,10 @@ import (
 	"fmt"
 	"net/mail"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/evalphobia/google-api-go-wrapper/calendar"
-	"github.com/evalphobia/google-api-go-wrapper/config"
+	SDK "google.golang.org/api/calendar/v3"
 
 	"github.com/eure/bobo/command"
 	"github.com/eure/bobo/library"
@@ -20,21 +19,22 @@ import (
 // (1): https://cloud.google.com/docs/authentication/
 // (2): https://developers.google.com/calendar/quickstart/go
 var CalendarCommand = command.BasicCommandTemplate{
-	Help:           "Get Events from Google Calendar",
+	Help:           "Get Events from Google Calendar [@user] [today|tomorrow|mon..fri|2024-06-03|next week]",
 	MentionCommand: "calendar",
 	GenerateFn: func(d command.CommandData) command.Command {
 		c := command.Command{}
 
-		calendarCli, err := getGoogleCalendarClient()
+		svc, err := getGoogleCalendarService(getSenderEmail(d))
 		if err != nil {
-			errMessage := fmt.Sprintf("[ERROR]\t[getGoogleCalendarClient]\t`%s`", err.Error())
+			errMessage := fmt.Sprintf("[ERROR]\t[getGoogleCalendarService]\t`%s`", err.Error())
 			task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
 			c.Add(task)
 			return c
 		}
 
 		// get email address for target calendar
-		email, err := getEmailAddress(d)
+		target, dateText := splitCalendarArgs(d.TextOther)
+		email, err := getEmailAddressFromText(d, target)
 		if err != nil {
 			errMessage := fmt.Sprintf("[ERROR]\t[getEmailAddress]\t`%s`", err.Error())
 			task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
@@ -42,9 +42,17 @@ var CalendarCommand = command.BasicCommandTemplate{
 			return c
 		}
 
+		now := time.Now().In(getUserLocation(d))
+		r, err := parseAgendaRange(dateText, now)
+		if err != nil {
+			task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Invalid date format: [%s]", dateText))
+			c.Add(task)
+			return c
+		}
+
 		// fetch events from google calendar
 		command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Getting events of [%s] ...", email)).Run()
-		list, err := calendarCli.EventList(email, 10)
+		list, err := fetchCalendarEvents(svc, email, r.Start, r.End)
 		if err != nil {
 			errMessage := fmt.Sprintf("[ERROR]\t[EventList]\t`%s`", err.Error())
 			task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
@@ -53,18 +61,38 @@ var CalendarCommand = command.BasicCommandTemplate{
 		}
 
 		// format and output events to slack
-		msg := formatCalendarAsSlackMessage(list.List)
+		msg := formatCalendarAsSlackMessage(list, r, now.Location())
 		task := command.NewReplyEngineTask(d.Engine, d.Channel, msg)
 		c.Add(task)
 		return c
 	},
 }
 
+// splitCalendarArgs splits arguments into target (@mention or email) and date words.
+func splitCalendarArgs(text string) (target, dateText string) {
+	words := strings.Fields(text)
+	others := make([]string, 0, len(words))
+	for _, w := range words {
+		if target == "" && strings.Contains(w, "@") {
+			target = w
+			continue
+		}
+		others = append(others, w)
+	}
+	return target, strings.Join(others, " ")
+}
+
 // returns email address in d.TextOther.
 // if it's empty returns sender's email address .
 func getEmailAddress(d command.CommandData) (string, error) {
-	// add atmark as a mention when d.TextOther is empty
-	text := library.TrimSigns(d.TextOther)
+	return getEmailAddressFromText(d, d.TextOther)
+}
+
+// returns email address in the text.
+// if it's empty returns sender's email address .
+func getEmailAddressFromText(d command.CommandData, text string) (string, error) {
+	// add atmark as a mention when text is empty
+	text = library.TrimSigns(text)
 	if text == "" {
 		text = "@" + d.SenderID
 	}
@@ -87,39 +115,110 @@ func getEmailAddress(d command.CommandData) (string, error) {
 	}
 }
 
-func formatCalendarAsSlackMessage(list []calendar.Event) string {
-	now := time.Now()
-	borderTime := now.AddDate(0, 0, 1)
-
-	result := make([]string, 0, len(list))
+func formatCalendarAsSlackMessage(list []calendar.Event, r agendaRange, loc *time.Location) string {
+	result := make([]string, 0, len(list)+10)
 	result = append(result, "```")
-	for _, ev := range list {
-		// Skip events after tommorow
-		if ev.StartTime.After(borderTime) {
+	if len(list) == 0 {
+		result = append(result, i18n.Message("No events"))
+	}
+
+	for _, day := range r.Days() {
+		lines := make([]string, 0, len(list))
+		for _, ev := range list {
+			span := newEventSpan(ev, loc)
+			if !span.IsOnDay(day) {
+				continue
+			}
+			lines = append(lines, span.format(ev, day))
+		}
+		if len(lines) == 0 {
 			continue
 		}
 
-		// for a one-line message
-		msg := make([]string, 0, 10)
+		result = append(result, fmt.Sprintf("■ %s (%s)", formatLocalDate(day), i18n.Message(day.Weekday().String())))
+		result = append(result, lines...)
+	}
+	result = append(result, "```")
 
-		// add datetime
-		switch {
-		case ev.IsAllDayEvent:
-			msg = append(msg, i18n.Message("[AllDay] [%s - %s]", formatLocalDate(ev.StartTime), formatLocalDate(ev.EndTime)))
-		default:
-			msg = append(msg, fmt.Sprintf("[%s - %s]", formatLocalDateTime(ev.StartTime), formatLocalTime(ev.EndTime)))
-		}
+	return strings.Join(result, "\n")
+}
+
+// eventSpan is a local time range of the event.
+type eventSpan struct {
+	Start    time.Time
+	End      time.Time // exclusive
+	IsAllDay bool
+}
 
-		// Add summary and location
-		msg = append(msg, ev.Summary)
-		if ev.Location != "" {
-			msg = append(msg, " ("+ev.Location+")")
+func newEventSpan(ev calendar.Event, loc *time.Location) eventSpan {
+	if !ev.IsAllDayEvent {
+		return eventSpan{
+			Start: ev.StartTime.In(loc),
+			End:   ev.EndTime.In(loc),
 		}
-		result = append(result, strings.Join(msg, " "))
 	}
-	result = append(result, "```")
 
-	return strings.Join(result, "\n")
+	// dates of all-day events are parsed as UTC.
+	return eventSpan{
+		Start:    time.Date(ev.StartTime.Year(), ev.StartTime.Month(), ev.StartTime.Day(), 0, 0, 0, 0, loc),
+		End:      time.Date(ev.EndTime.Year(), ev.EndTime.Month(), ev.EndTime.Day(), 0, 0, 0, 0, loc),
+		IsAllDay: true,
+	}
+}
+
+// IsOnDay checks the event is held on the day.
+func (s eventSpan) IsOnDay(day time.Time) bool {
+	dayEnd := day.AddDate(0, 0, 1)
+	if !s.End.After(s.Start) {
+		return !s.Start.Before(day) && s.Start.Before(dayEnd)
+	}
+	return s.Start.Before(dayEnd) && s.End.After(day)
+}
+
+// Days returns the number of days the event covers.
+func (s eventSpan) Days() int {
+	days := 0
+	for dt := truncateDay(s.Start); dt.Before(s.End); dt = dt.AddDate(0, 0, 1) {
+		days++
+	}
+	if days == 0 {
+		return 1
+	}
+	return days
+}
+
+// LastDay returns the last day of the event.
+func (s eventSpan) LastDay() time.Time {
+	return truncateDay(s.Start).AddDate(0, 0, s.Days()-1)
+}
+
+func (s eventSpan) format(ev calendar.Event, day time.Time) string {
+	// for a one-line message
+	msg := make([]string, 0, 10)
+
+	// add datetime
+	days := s.Days()
+	switch {
+	case s.IsAllDay && days > 1:
+		msg = append(msg, i18n.Message("[AllDay] [%s - %s]", formatLocalDate(s.Start), formatLocalDate(s.LastDay())))
+	case s.IsAllDay:
+		msg = append(msg, i18n.Message("[AllDay]"))
+	case days > 1:
+		msg = append(msg, fmt.Sprintf("[%s - %s]", formatLocalDateTime(s.Start), formatLocalDateTime(s.End)))
+	default:
+		msg = append(msg, fmt.Sprintf("[%s - %s]", formatLocalTime(s.Start), formatLocalTime(s.End)))
+	}
+
+	// Add summary and location
+	msg = append(msg, ev.Summary)
+	if ev.Location != "" {
+		msg = append(msg, " ("+ev.Location+")")
+	}
+	if days > 1 {
+		nth := int(truncateDay(day).Sub(truncateDay(s.Start)).Hours()/24+0.5) + 1
+		msg = append(msg, i18n.Message("(day %d/%d)", nth, days))
+	}
+	return strings.Join(msg, " ")
 }
 
 func formatLocalDateTime(dt time.Time) string {
@@ -134,13 +233,32 @@ func formatLocalDate(dt time.Time) string {
 	return dt.Format("01/02")
 }
 
-var calendarOnce sync.Once
-var calendarCli *calendar.Calendar
+// fetchCalendarEvents fetches all of the events between start and end.
+// google-api-go-wrapper does not return page token of events, so use SDK service directly.
+func fetchCalendarEvents(svc *SDK.Service, calendarID string, start, end time.Time) ([]calendar.Event, error) {
+	var results []calendar.Event
+	nextPageToken := ""
+	for {
+		call := svc.Events.List(calendarID).
+			TimeMin(start.Format(time.RFC3339)).
+			TimeMax(end.Format(time.RFC3339)).
+			SingleEvents(true).
+			OrderBy("startTime").
+			MaxResults(250)
+		if nextPageToken != "" {
+			call.PageToken(nextPageToken)
+		}
+
+		resp, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, calendar.NewEvents(resp.Items)...)
 
-func getGoogleCalendarClient() (*calendar.Calendar, error) {
-	var err error
-	calendarOnce.Do(func() {
-		calendarCli, err = calendar.New(config.Config{})
-	})
-	return calendarCli, err
+		if resp.NextPageToken == "" {
+			break
+		}
+		nextPageToken = resp.NextPageToken
+	}
+	return results, nil
 }
@@ -0,0 +1,309 @@
+package google
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eure/bobo/command"
+	SDK "google.golang.org/api/calendar/v3"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var _ command.CommandTemplate = &CalendarAddCommand{}
+
+// CalendarAddCommand creates an event on the sender's calendar and books the room.
+type CalendarAddCommand struct{}
+
+func (CalendarAddCommand) GetMentionCommand() string {
+	return "calendar:add"
+}
+
+func (CalendarAddCommand) GetHelp() string {
+	return "Create an event on Google Calendar \"<title>\" [tomorrow|2024-06-03] <15:00> [30m] [@user...] [in <room>|in:<room>] [--force]"
+}
+
+func (CalendarAddCommand) HasHelp() bool {
+	return true
+}
+
+func (CalendarAddCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a *CalendarAddCommand) Exec(d command.CommandData) {
+	c := a.runCalendarAdd(d)
+	c.Exec()
+}
+
+// main logic.
+func (a *CalendarAddCommand) runCalendarAdd(d command.CommandData) command.Command {
+	c := command.Command{}
+
+	svc, err := getGoogleCalendarService(getSenderEmail(d))
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[getGoogleCalendarService]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	opt, err := parseCalendarAddOption(d.TextOther, time.Now().In(getUserLocation(d)))
+	if err != nil {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, err.Error())
+		c.Add(task)
+		return c
+	}
+
+	// the first email is the organizer.
+	emails, err := getMeetParticipants(d, append([]string{""}, opt.Targets...))
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[getEmailAddress]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	var room calendarRoom
+	if opt.Room != "" {
+		room, err = findRoomByName(svc, opt.Room)
+		if err != nil {
+			task := command.NewReplyEngineTask(d.Engine, d.Channel, err.Error())
+			c.Add(task)
+			return c
+		}
+	}
+
+	// check conflicts.
+	span := timeSpan{Start: opt.Start, End: opt.Start.Add(opt.Duration)}
+	ids := emails
+	if room.ID != "" {
+		ids = append(ids, room.ID)
+	}
+	fb, err := fetchFreeBusy(svc, ids, span.Start, span.End)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[Freebusy]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+	if reason, ok := fb.Errors[room.ID]; room.ID != "" && ok {
+		errMessage := fmt.Sprintf("[ERROR]\t[Freebusy]\t[%s]\t`%s`", room.Name, reason)
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+	if room.ID != "" && !fb.IsFree(room.ID, span) {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Room [%s] is not available at [%s - %s]", room.Name, formatLocalDateTime(span.Start), formatLocalTime(span.End)))
+		c.Add(task)
+		return c
+	}
+	if !opt.Force {
+		var busy []string
+		for _, email := range emails {
+			if _, ok := fb.Errors[email]; ok {
+				continue
+			}
+			if !fb.IsFree(email, span) {
+				busy = append(busy, email)
+			}
+		}
+		if len(busy) != 0 {
+			task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Conflicts with existing events of [%s]. Add --force to create anyway.", strings.Join(busy, ", ")))
+			c.Add(task)
+			return c
+		}
+	}
+
+	ev := &SDK.Event{
+		Summary: opt.Title,
+		Start:   &SDK.EventDateTime{DateTime: span.Start.Format(time.RFC3339)},
+		End:     &SDK.EventDateTime{DateTime: span.End.Format(time.RFC3339)},
+	}
+	for _, email := range emails {
+		ev.Attendees = append(ev.Attendees, &SDK.EventAttendee{Email: email})
+	}
+	if room.ID != "" {
+		ev.Location = room.Name
+		ev.Attendees = append(ev.Attendees, &SDK.EventAttendee{
+			Email:    room.ID,
+			Resource: true,
+		})
+	}
+
+	created, err := svc.Events.Insert(emails[0], ev).SendUpdates("all").Do()
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[Events.Insert]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	msg := i18n.Message("Created [%s] (%s - %s): %s", created.Summary, formatLocalDateTime(span.Start), formatLocalTime(span.End), created.HtmlLink)
+	task := command.NewReplyEngineTask(d.Engine, d.Channel, msg)
+	c.Add(task)
+	return c
+}
+
+type calendarRoom struct {
+	ID   string
+	Name string
+}
+
+// findRoomByName finds a resource calendar by name or id.
+// Exact match is preferred to partial match.
+func findRoomByName(svc *SDK.Service, name string) (calendarRoom, error) {
+	list, err := fetchAllResourceCalendars(svc)
+	if err != nil {
+		return calendarRoom{}, err
+	}
+
+	lower := strings.ToLower(name)
+	var candidates []calendarRoom
+	for _, v := range list {
+		summary := strings.ToLower(v.Summary)
+		switch {
+		case v.ID == name, summary == lower:
+			return calendarRoom{ID: v.ID, Name: v.Summary}, nil
+		case strings.Contains(summary, lower):
+			candidates = append(candidates, calendarRoom{ID: v.ID, Name: v.Summary})
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return calendarRoom{}, errors.New(i18n.Message("Room [%s] is not found", name))
+	case 1:
+		return candidates[0], nil
+	}
+	names := make([]string, len(candidates))
+	for i, r := range candidates {
+		names[i] = r.Name
+	}
+	return calendarRoom{}, errors.New(i18n.Message("Room [%s] matches multiple rooms: %s", name, strings.Join(names, ", ")))
+}
+
+// calendarAddOption is parsed arguments of calendar:add command.
+type calendarAddOption struct {
+	Title    string
+	Start    time.Time
+	Duration time.Duration
+	Targets  []string
+	Room     string
+	Force    bool
+}
+
+var (
+	reCalendarAddTitle = regexp.MustCompile(`["“”](.+?)["“”]`)
+	reCalendarAddClock = regexp.MustCompile(`^(\d{1,2})(?::(\d{2})|時(?:(\d{1,2})分)?|時半)$`)
+)
+
+func parseCalendarAddOption(text string, now time.Time) (calendarAddOption, error) {
+	opt := calendarAddOption{
+		Duration: defaultMeetDuration,
+	}
+
+	if m := reCalendarAddTitle.FindStringSubmatch(text); len(m) != 0 {
+		opt.Title = strings.TrimSpace(m[1])
+		text = strings.Replace(text, m[0], " ", 1)
+	}
+
+	today := truncateDay(now)
+	day := today
+	var clock time.Duration
+	hasClock := false
+	var others []string
+
+	words := strings.Fields(text)
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		switch {
+		case w == "--force":
+			opt.Force = true
+		case strings.HasPrefix(w, "in:"):
+			// the rest of words is the room name.
+			words[i] = strings.TrimPrefix(w, "in:")
+			opt.Room, opt.Force = parseCalendarAddRoom(words[i:], opt.Force)
+			i = len(words)
+		case w == "in" && hasClock && i+1 < len(words):
+			// "in" before the start time is a part of the title. e.g.) "Check in with Bob"
+			opt.Room, opt.Force = parseCalendarAddRoom(words[i+1:], opt.Force)
+			i = len(words)
+		case strings.Contains(w, "@"):
+			opt.Targets = append(opt.Targets, w)
+		default:
+			if dur, ok := parseClockText(w); ok {
+				clock = dur
+				hasClock = true
+				continue
+			}
+			if dt, err := parseAgendaDay(strings.ToLower(w), today); err == nil {
+				day = dt
+				continue
+			}
+			if dur, ok := parseMeetDuration(w); ok {
+				opt.Duration = dur
+				continue
+			}
+			others = append(others, w)
+		}
+	}
+
+	if opt.Title == "" {
+		opt.Title = strings.Join(others, " ")
+	}
+	switch {
+	case opt.Title == "":
+		return opt, errors.New(i18n.Message("Set the title of the event"))
+	case !hasClock:
+		return opt, errors.New(i18n.Message("Set the start time of the event. e.g.) 15:00"))
+	case opt.Duration <= 0 || opt.Duration > 24*time.Hour:
+		return opt, errors.New(i18n.Message("Invalid duration: [%s]", opt.Duration.String()))
+	}
+
+	opt.Start = atClock(day, clock)
+	if opt.Start.Before(now) {
+		return opt, errors.New(i18n.Message("The start time has already passed: [%s]", formatLocalDateTime(opt.Start)))
+	}
+	return opt, nil
+}
+
+// parseCalendarAddRoom returns the room name from the rest of words, and "--force" in them.
+func parseCalendarAddRoom(words []string, force bool) (string, bool) {
+	names := make([]string, 0, len(words))
+	for _, w := range words {
+		if w == "--force" {
+			force = true
+			continue
+		}
+		names = append(names, w)
+	}
+	return strings.TrimSpace(strings.Join(names, " ")), force
+}
+
+// parseClockText parses "15:00", "15時", "15時30分" and "15時半".
+func parseClockText(text string) (time.Duration, bool) {
+	m := reCalendarAddClock.FindStringSubmatch(text)
+	if len(m) == 0 {
+		return 0, false
+	}
+
+	h, _ := strconv.Atoi(m[1])
+	min := 0
+	switch {
+	case m[2] != "":
+		min, _ = strconv.Atoi(m[2])
+	case m[3] != "":
+		min, _ = strconv.Atoi(m[3])
+	case strings.HasSuffix(text, "時半"):
+		min = 30
+	}
+	if h > 23 || min > 59 {
+		return 0, false
+	}
+	return time.Duration(h)*time.Hour + time.Duration(min)*time.Minute, true
+}
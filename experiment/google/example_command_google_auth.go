@@ -0,0 +1,115 @@
+package google
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var _ command.CommandTemplate = &GoogleAuthCommand{}
+
+// GoogleAuthCommand authorizes the sender's Google account on GOOGLE_AUTH_TYPE=user_oauth.
+// It works only in a direct message, because the code is posted.
+type GoogleAuthCommand struct{}
+
+func (GoogleAuthCommand) GetMentionCommand() string {
+	return "google:auth"
+}
+
+func (GoogleAuthCommand) GetHelp() string {
+	return "Authorize your Google account for Google commands in a direct message [<redirected URL>]"
+}
+
+func (GoogleAuthCommand) HasHelp() bool {
+	return true
+}
+
+func (GoogleAuthCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a *GoogleAuthCommand) Exec(d command.CommandData) {
+	c := a.runGoogleAuth(d)
+	c.Exec()
+}
+
+// main logic.
+func (a *GoogleAuthCommand) runGoogleAuth(d command.CommandData) command.Command {
+	c := command.Command{}
+
+	auth, ok := getGoogleAuthProvider().(*userOAuthAuth)
+	if !ok {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Authorization is not needed. This command is only for GOOGLE_AUTH_TYPE=user_oauth"))
+		c.Add(task)
+		return c
+	}
+
+	if !d.IsDM {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Run `google:auth` in a direct message to the bot"))
+		c.Add(task)
+		return c
+	}
+
+	email := getSenderEmail(d)
+	if email == "" {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, "[ERROR]\t[getSenderEmail]\t`Cannot get email address of the sender`")
+		c.Add(task)
+		return c
+	}
+
+	text := strings.TrimSpace(d.TextOther)
+	if text == "" {
+		authURL, err := auth.AuthCodeURL(d.SenderID)
+		if err != nil {
+			errMessage := fmt.Sprintf("[ERROR]\t[AuthCodeURL]\t`%s`", err.Error())
+			task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+			c.Add(task)
+			return c
+		}
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Open the URL with [%s] and run `google:auth <redirected URL>` with the URL of the redirected page: %s", email, authURL))
+		c.Add(task)
+		return c
+	}
+
+	code, state := parseGoogleAuthCode(text)
+	if code == "" || state == "" {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Set the URL of the redirected page, which has code and state"))
+		c.Add(task)
+		return c
+	}
+	if err := auth.Exchange(d.SenderID, email, code, state); err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[Exchange]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Authorized Google account [%s]", email))
+	c.Add(task)
+	return c
+}
+
+// parseGoogleAuthCode returns code and state from the URL of the redirected page.
+// "<code> <state>" is also supported for a redirect page which shows them.
+func parseGoogleAuthCode(text string) (code, state string) {
+	// Slack escapes URL. e.g.) "<http://localhost/?state=xxx&amp;code=yyy>"
+	text = html.UnescapeString(strings.Trim(text, "<>"))
+	if u, err := url.Parse(text); err == nil && u.Query().Get("code") != "" {
+		return u.Query().Get("code"), u.Query().Get("state")
+	}
+
+	fields := strings.Fields(text)
+	switch len(fields) {
+	case 2:
+		return fields[0], fields[1]
+	case 1:
+		return fields[0], ""
+	}
+	return "", ""
+}
@@ -0,0 +1,346 @@
+package google
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eure/bobo/command"
+	SDK "google.golang.org/api/calendar/v3"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+const (
+	defaultMeetDuration   = 30 * time.Minute
+	defaultMeetStep       = 30 * time.Minute
+	defaultMeetDays       = 7
+	defaultMeetCandidates = 5
+	defaultMeetWorkStart  = "10:00"
+	defaultMeetWorkEnd    = "19:00"
+)
+
+var _ command.CommandTemplate = &MeetCommand{}
+
+// MeetCommand finds common free slots of participants by freebusy API.
+type MeetCommand struct {
+	WorkStart       string // start of working hours. (default: 10:00)
+	WorkEnd         string // end of working hours. (default: 19:00)
+	IncludeWeekends bool
+	MaxCandidates   int
+	// IncludeRoom adds an available room to each candidate.
+	IncludeRoom bool
+	// Room shares the room list and filters of RoomCommand. (optional)
+	Room *RoomCommand
+
+	directory roomDirectory
+}
+
+func (*MeetCommand) GetMentionCommand() string {
+	return "meet"
+}
+
+func (*MeetCommand) GetHelp() string {
+	return "Find free slots for a meeting [@user...] [30m|1h] [today|tomorrow|mon..fri|next week]"
+}
+
+func (*MeetCommand) HasHelp() bool {
+	return true
+}
+
+func (*MeetCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a *MeetCommand) Exec(d command.CommandData) {
+	c := a.runMeet(d)
+	c.Exec()
+}
+
+// main logic.
+func (a *MeetCommand) runMeet(d command.CommandData) command.Command {
+	c := command.Command{}
+
+	sender := getSenderEmail(d)
+	svc, err := getGoogleCalendarService(sender)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[getGoogleCalendarService]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	opt, err := parseMeetOption(d.TextOther)
+	if err != nil {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, err.Error())
+		c.Add(task)
+		return c
+	}
+
+	// the sender is always a participant.
+	emails, err := getMeetParticipants(d, append([]string{""}, opt.Targets...))
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[getEmailAddress]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	now := time.Now().In(getUserLocation(d))
+	r := agendaRange{Start: now, End: truncateDay(now).AddDate(0, 0, defaultMeetDays)}
+	if opt.DateText != "" {
+		r, err = parseAgendaRange(opt.DateText, now)
+		if err != nil {
+			task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Invalid date format: [%s]", opt.DateText))
+			c.Add(task)
+			return c
+		}
+	}
+
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Finding free slots of [%s] ...", strings.Join(emails, ", "))).Run()
+	fb, err := fetchFreeBusy(svc, emails, r.Start, r.End)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[Freebusy]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	// calendars which cannot be checked are ignored.
+	busy := make([]timeSpan, 0, 64)
+	for _, email := range emails {
+		if reason, ok := fb.Errors[email]; ok {
+			_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Cannot get free/busy of [%s]: %s", email, reason)).Run()
+			continue
+		}
+		busy = append(busy, fb.Busy[email]...)
+	}
+
+	slots := a.findSlots(r, now, busy, opt.Duration)
+	if a.IncludeRoom && len(slots) != 0 {
+		if err := a.setRooms(svc, sender, slots, r); err != nil {
+			task := command.NewReplyEngineTask(d.Engine, d.Channel, err.Error())
+			c.Add(task)
+			return c
+		}
+	}
+
+	msg := "```\n" + formatMeetSlots(slots, opt.Duration) + "\n```"
+	task := command.NewReplyEngineTask(d.Engine, d.Channel, msg)
+	c.Add(task)
+	return c
+}
+
+// meetSlot is a candidate of the meeting.
+type meetSlot struct {
+	timeSpan
+	FreeUntil time.Time
+	Room      string
+}
+
+// findSlots returns the first slot of each free time within working hours.
+func (a *MeetCommand) findSlots(r agendaRange, now time.Time, busy []timeSpan, duration time.Duration) []meetSlot {
+	workStart := parseClock(a.WorkStart, defaultMeetWorkStart)
+	workEnd := parseClock(a.WorkEnd, defaultMeetWorkEnd)
+	max := a.getMaxCandidates()
+
+	slots := make([]meetSlot, 0, max)
+	for _, day := range r.Days() {
+		if !a.IncludeWeekends && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+
+		window := timeSpan{Start: atClock(day, workStart), End: atClock(day, workEnd)}
+		if window.Start.Before(now) {
+			window.Start = now.Truncate(defaultMeetStep).Add(defaultMeetStep)
+		}
+		if !window.Start.Before(window.End) {
+			continue
+		}
+
+		for _, free := range subtractTimeSpans(window, busy) {
+			// start on the step. e.g.) 10:00, 10:30
+			start := free.Start.In(day.Location())
+			if t := start.Truncate(defaultMeetStep); t.Before(start) {
+				start = t.Add(defaultMeetStep)
+			}
+			if start.Add(duration).After(free.End) {
+				continue
+			}
+
+			slots = append(slots, meetSlot{
+				timeSpan:  timeSpan{Start: start, End: start.Add(duration)},
+				FreeUntil: free.End.In(day.Location()),
+			})
+			if len(slots) >= max {
+				return slots
+			}
+		}
+	}
+	return slots
+}
+
+// setRooms sets an available room to each slot.
+func (a *MeetCommand) setRooms(svc *SDK.Service, sender string, slots []meetSlot, r agendaRange) error {
+	rooms, err := a.getRooms(sender)
+	if err != nil {
+		return err
+	}
+	if len(rooms) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(rooms))
+	for i, room := range rooms {
+		ids[i] = room.ID
+	}
+	fb, err := fetchFreeBusy(svc, ids, r.Start, r.End)
+	if err != nil {
+		return fmt.Errorf("[ERROR]\t[Freebusy]\t`%s`", err.Error())
+	}
+
+	for i := range slots {
+		for _, room := range rooms {
+			if fb.IsFree(room.ID, slots[i].timeSpan) {
+				slots[i].Room = room.getName()
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (a *MeetCommand) getRooms(sender string) ([]RoomInfo, error) {
+	if a.Room != nil {
+		return a.Room.directory.Get(a.Room.getCacheTTL(), func() ([]RoomInfo, error) {
+			return a.Room.fetchRooms(sender)
+		})
+	}
+	return a.directory.Get(defaultRoomCacheTTL, func() ([]RoomInfo, error) {
+		return fetchRoomInfos(sender, "", false)
+	})
+}
+
+func (a *MeetCommand) getMaxCandidates() int {
+	if a.MaxCandidates > 0 {
+		return a.MaxCandidates
+	}
+	return defaultMeetCandidates
+}
+
+func formatMeetSlots(slots []meetSlot, duration time.Duration) string {
+	if len(slots) == 0 {
+		return i18n.Message("No common free slots")
+	}
+
+	results := make([]string, 0, len(slots)+1)
+	results = append(results, i18n.Message("[Meeting Candidates] %s", formatDuration(duration)))
+	for i, s := range slots {
+		line := fmt.Sprintf("%d. %s (%s) %s - %s", i+1, formatLocalDate(s.Start), i18n.Message(s.Start.Weekday().String()), formatLocalTime(s.Start), formatLocalTime(s.End))
+		if s.FreeUntil.After(s.End) {
+			line += " " + i18n.Message("(free until %s)", formatLocalTime(s.FreeUntil))
+		}
+		if s.Room != "" {
+			line += " @ " + s.Room
+		}
+		results = append(results, line)
+	}
+	return strings.Join(results, "\n")
+}
+
+// meetOption is parsed arguments of the meet command.
+type meetOption struct {
+	Targets  []string
+	Duration time.Duration
+	DateText string
+}
+
+func parseMeetOption(text string) (meetOption, error) {
+	opt := meetOption{
+		Duration: defaultMeetDuration,
+	}
+
+	others := make([]string, 0, 4)
+	for _, w := range strings.Fields(text) {
+		if strings.Contains(w, "@") {
+			opt.Targets = append(opt.Targets, w)
+			continue
+		}
+		if dur, ok := parseMeetDuration(w); ok {
+			opt.Duration = dur
+			continue
+		}
+		others = append(others, w)
+	}
+	opt.DateText = strings.Join(others, " ")
+
+	if opt.Duration <= 0 || opt.Duration > 8*time.Hour {
+		return opt, errors.New(i18n.Message("Invalid duration: [%s]", opt.Duration.String()))
+	}
+	return opt, nil
+}
+
+var reMeetJADuration = regexp.MustCompile(`^(?:(\d+)時間)?(?:(\d+)分)?$`)
+
+// parseMeetDuration parses "30m", "1h30m", "30" (minutes), "1時間30分".
+func parseMeetDuration(text string) (time.Duration, bool) {
+	if n, err := strconv.Atoi(text); err == nil {
+		return time.Duration(n) * time.Minute, true
+	}
+	if dur, err := time.ParseDuration(text); err == nil {
+		return dur, true
+	}
+
+	m := reMeetJADuration.FindStringSubmatch(text)
+	if len(m) == 0 || (m[1] == "" && m[2] == "") {
+		return 0, false
+	}
+	h, _ := strconv.Atoi(m[1])
+	min, _ := strconv.Atoi(m[2])
+	return time.Duration(h)*time.Hour + time.Duration(min)*time.Minute, true
+}
+
+// getMeetParticipants returns unique email addresses of the targets.
+func getMeetParticipants(d command.CommandData, targets []string) ([]string, error) {
+	emails := make([]string, 0, len(targets))
+	seen := make(map[string]struct{}, len(targets))
+	for _, t := range targets {
+		email, err := getEmailAddressFromText(d, t)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[email]; ok {
+			continue
+		}
+		seen[email] = struct{}{}
+		emails = append(emails, email)
+	}
+	return emails, nil
+}
+
+// parseClock parses "HH:MM" as duration from midnight.
+func parseClock(text, defaultValue string) time.Duration {
+	if text == "" {
+		text = defaultValue
+	}
+	dt, err := time.Parse("15:04", text)
+	if err != nil {
+		dt, _ = time.Parse("15:04", defaultValue)
+	}
+	return time.Duration(dt.Hour())*time.Hour + time.Duration(dt.Minute())*time.Minute
+}
+
+func formatDuration(d time.Duration) string {
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	switch {
+	case h == 0:
+		return i18n.Message("%d min", m)
+	case m == 0:
+		return i18n.Message("%d hour", h)
+	}
+	return i18n.Message("%d hour %d min", h, m)
+}
@@ -0,0 +1,411 @@
+package google
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eure/bobo/command"
+	"github.com/eure/bobo/log"
+	SDK "google.golang.org/api/calendar/v3"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+	"github.com/evalphobia/bobo-experiment/scheduler"
+	"github.com/evalphobia/bobo-experiment/slackapi"
+	"github.com/evalphobia/bobo-experiment/storage"
+)
+
+const (
+	defaultReminderStateFile = "meeting_reminder.json"
+	defaultReminderBefore    = 5 * time.Minute
+	defaultReminderInterval  = time.Minute
+	maxReminderBefore        = 2 * time.Hour
+	maxReminderAttendees     = 10
+)
+
+var _ command.CommandTemplate = &MeetingReminderCommand{}
+
+// MeetingReminderCommand sends a direct message before each meeting of subscribed users.
+// Run must be called to watch calendars in background.
+type MeetingReminderCommand struct {
+	// Engine is used for sending direct messages.
+	// Set the same engine which is used in bobo.Run.
+	// Reminders start after the engine is initialized.
+	Engine *scheduler.ReadyEngine
+	Logger log.Logger
+
+	// StateFile is a file path to save subscriptions and notified meetings.
+	// If it's empty, envvar MEETING_REMINDER_STATE_FILE is used.
+	StateFile string
+	// Interval is polling interval of calendars. (default: 1m)
+	Interval time.Duration
+
+	initOnce sync.Once
+	initErr  error
+	store    *storage.JSONFile
+	mu       sync.Mutex
+	state    reminderState
+}
+
+// reminderState is persisted in the state file.
+type reminderState struct {
+	Subscriptions map[string]reminderSubscription `json:"subscriptions"` // key=UserID
+	// Notified contains meetings already notified. key=UserID|iCalUID|start, value=start
+	Notified map[string]time.Time `json:"notified"`
+}
+
+type reminderSubscription struct {
+	UserID        string `json:"user_id"`
+	Email         string `json:"email"`
+	BeforeMinutes int    `json:"before_minutes"`
+}
+
+func (s reminderSubscription) Before() time.Duration {
+	return time.Duration(s.BeforeMinutes) * time.Minute
+}
+
+func (*MeetingReminderCommand) GetMentionCommand() string {
+	return "remind"
+}
+
+func (*MeetingReminderCommand) GetHelp() string {
+	return "Send DM before your meetings [me meetings 5m|off]"
+}
+
+func (*MeetingReminderCommand) HasHelp() bool {
+	return true
+}
+
+func (*MeetingReminderCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a *MeetingReminderCommand) Exec(d command.CommandData) {
+	c := a.runRemind(d)
+	c.Exec()
+}
+
+// main logic.
+func (a *MeetingReminderCommand) runRemind(d command.CommandData) command.Command {
+	c := command.Command{}
+
+	if err := a.init(); err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[MeetingReminder]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	words := strings.Fields(strings.ToLower(d.TextOther))
+	switch {
+	case len(words) == 1 && words[0] == "off":
+		if err := a.unsubscribe(d.SenderID); err != nil {
+			errMessage := fmt.Sprintf("[ERROR]\t[unsubscribe]\t`%s`", err.Error())
+			task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+			c.Add(task)
+			return c
+		}
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Meeting reminders are turned off"))
+		c.Add(task)
+		return c
+	case len(words) >= 2 && words[0] == "me" && words[1] == "meetings":
+		// subscribe below.
+	default:
+		msg := i18n.Message("Meeting reminders are off")
+		if sub, ok := a.getSubscription(d.SenderID); ok {
+			msg = i18n.Message("Meeting reminders are on: %s before", formatDuration(sub.Before()))
+		}
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, msg)
+		c.Add(task)
+		return c
+	}
+
+	before := defaultReminderBefore
+	if len(words) > 2 {
+		dur, ok := parseMeetDuration(words[2])
+		if !ok || dur < time.Minute || dur > maxReminderBefore {
+			task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Invalid duration: [%s]", words[2]))
+			c.Add(task)
+			return c
+		}
+		before = dur
+	}
+
+	email := getSenderEmail(d)
+	if email == "" {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, "[ERROR]\t[getSenderEmail]\t`Cannot get email address of the sender`")
+		c.Add(task)
+		return c
+	}
+
+	err := a.subscribe(reminderSubscription{
+		UserID:        d.SenderID,
+		Email:         email,
+		BeforeMinutes: int(before / time.Minute),
+	})
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[subscribe]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("You will get a DM %s before each meeting. Stop it by `remind off`", formatDuration(before)))
+	c.Add(task)
+	return c
+}
+
+// Run starts watching calendars of subscribed users.
+// It blocks forever.
+func (a *MeetingReminderCommand) Run() error {
+	if a.Engine == nil {
+		return fmt.Errorf("[MeetingReminder] Engine is nil")
+	}
+	if err := a.init(); err != nil {
+		return err
+	}
+
+	<-a.Engine.Ready()
+	a.logInfo("started: subscriptions=[%d]", len(a.getSubscriptions()))
+
+	interval := a.getInterval()
+	for {
+		now := time.Now()
+		next := now.Truncate(interval).Add(interval)
+		time.Sleep(next.Sub(now))
+		a.checkMeetings(next)
+	}
+}
+
+func (a *MeetingReminderCommand) init() error {
+	a.initOnce.Do(func() {
+		if a.Logger == nil {
+			a.Logger = log.DefaultLogger
+		}
+		a.store = storage.NewJSONFile(a.getStateFile())
+		a.initErr = a.store.Load(&a.state)
+		if a.state.Subscriptions == nil {
+			a.state.Subscriptions = make(map[string]reminderSubscription)
+		}
+		if a.state.Notified == nil {
+			a.state.Notified = make(map[string]time.Time)
+		}
+	})
+	return a.initErr
+}
+
+// checkMeetings sends reminders of the meetings which start soon.
+func (a *MeetingReminderCommand) checkMeetings(now time.Time) {
+	for _, sub := range a.getSubscriptions() {
+		events, err := fetchUpcomingEvents(sub.Email, now, now.Add(sub.Before()+a.getInterval()))
+		if err != nil {
+			a.logError("user=[%s] error=[%s]", sub.UserID, err.Error())
+			continue
+		}
+
+		for _, ev := range events {
+			start, ok := getReminderStart(ev)
+			if !ok || !start.After(now) || start.Sub(now) > sub.Before() {
+				continue
+			}
+
+			// instances of recurring event have the same iCalUID and different start time.
+			key := sub.UserID + "|" + ev.ICalUID + "|" + start.UTC().Format(time.RFC3339)
+			if a.isNotified(key) {
+				continue
+			}
+			// failed reminders are retried on the next check.
+			if err := a.sendReminder(sub, ev, start, now); err != nil {
+				a.logError("user=[%s] error=[%s]", sub.UserID, err.Error())
+				continue
+			}
+			a.markNotified(key, start)
+		}
+	}
+	a.pruneNotified(now)
+}
+
+func (a *MeetingReminderCommand) sendReminder(sub reminderSubscription, ev *SDK.Event, start, now time.Time) error {
+	channel, err := slackapi.OpenDirectMessage(sub.UserID)
+	if err != nil {
+		return err
+	}
+
+	loc := getLocationByUserID(sub.UserID, sub.Email)
+	start = start.In(loc)
+	minutes := int(start.Sub(now).Minutes() + 0.5)
+	lines := []string{
+		i18n.Message("[Reminder] [%s] starts at %s (in %d min)", ev.Summary, formatLocalTime(start), minutes),
+	}
+	if ev.Location != "" {
+		lines = append(lines, i18n.Message("Location: %s", ev.Location))
+	}
+	if link := getMeetLink(ev); link != "" {
+		lines = append(lines, i18n.Message("Meet: %s", link))
+	}
+	if names := getAttendeeNames(ev, maxReminderAttendees); names != "" {
+		lines = append(lines, i18n.Message("Attendees: %s", names))
+	}
+	return command.NewReplyEngineTask(a.Engine, channel, strings.Join(lines, "\n")).Run()
+}
+
+// fetchUpcomingEvents fetches events of the user between start and end.
+func fetchUpcomingEvents(email string, start, end time.Time) ([]*SDK.Event, error) {
+	svc, err := getGoogleCalendarService(email)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := svc.Events.List(email).
+		TimeMin(start.Format(time.RFC3339)).
+		TimeMax(end.Format(time.RFC3339)).
+		EventTypes(eventTypeDefault).
+		SingleEvents(true).
+		OrderBy("startTime").
+		MaxResults(50).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// getReminderStart returns start time of the meeting.
+// All-day, cancelled and declined events are ignored.
+func getReminderStart(ev *SDK.Event) (time.Time, bool) {
+	if ev.Status == "cancelled" || ev.Start == nil || ev.Start.DateTime == "" {
+		return time.Time{}, false
+	}
+	for _, at := range ev.Attendees {
+		if at.Self && at.ResponseStatus == "declined" {
+			return time.Time{}, false
+		}
+	}
+
+	start, err := time.Parse(time.RFC3339, ev.Start.DateTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// getMeetLink returns video link of the meeting.
+func getMeetLink(ev *SDK.Event) string {
+	if ev.ConferenceData != nil {
+		for _, p := range ev.ConferenceData.EntryPoints {
+			if p.EntryPointType == "video" {
+				return p.Uri
+			}
+		}
+	}
+	return ev.HangoutLink
+}
+
+// getAttendeeNames returns names of attendees except rooms.
+func getAttendeeNames(ev *SDK.Event, max int) string {
+	names := make([]string, 0, len(ev.Attendees))
+	for _, at := range ev.Attendees {
+		if at.Resource {
+			continue
+		}
+		name := at.DisplayName
+		if name == "" {
+			name = at.Email
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > max {
+		return strings.Join(names[:max], ", ") + " " + i18n.Message("and %d more", len(names)-max)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (a *MeetingReminderCommand) getSubscription(userID string) (reminderSubscription, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	sub, ok := a.state.Subscriptions[userID]
+	return sub, ok
+}
+
+func (a *MeetingReminderCommand) getSubscriptions() []reminderSubscription {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	list := make([]reminderSubscription, 0, len(a.state.Subscriptions))
+	for _, sub := range a.state.Subscriptions {
+		list = append(list, sub)
+	}
+	return list
+}
+
+func (a *MeetingReminderCommand) subscribe(sub reminderSubscription) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.state.Subscriptions[sub.UserID] = sub
+	return a.store.Save(a.state)
+}
+
+func (a *MeetingReminderCommand) unsubscribe(userID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.state.Subscriptions, userID)
+	return a.store.Save(a.state)
+}
+
+func (a *MeetingReminderCommand) isNotified(key string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, ok := a.state.Notified[key]
+	return ok
+}
+
+// markNotified saves the meeting as notified.
+func (a *MeetingReminderCommand) markNotified(key string, start time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.state.Notified[key] = start
+	if err := a.store.Save(a.state); err != nil {
+		a.logError("error=[%s]", err.Error())
+	}
+}
+
+// pruneNotified removes meetings which have already started.
+func (a *MeetingReminderCommand) pruneNotified(now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for key, start := range a.state.Notified {
+		if start.Before(now.Add(-1 * time.Hour)) {
+			delete(a.state.Notified, key)
+		}
+	}
+}
+
+func (a *MeetingReminderCommand) getStateFile() string {
+	switch {
+	case a.StateFile != "":
+		return a.StateFile
+	case os.Getenv("MEETING_REMINDER_STATE_FILE") != "":
+		return os.Getenv("MEETING_REMINDER_STATE_FILE")
+	}
+	return defaultReminderStateFile
+}
+
+func (a *MeetingReminderCommand) getInterval() time.Duration {
+	if a.Interval > 0 {
+		return a.Interval
+	}
+	return defaultReminderInterval
+}
+
+func (a *MeetingReminderCommand) logInfo(format string, v ...interface{}) {
+	a.Logger.Infof("MeetingReminder", format, v...)
+}
+
+func (a *MeetingReminderCommand) logError(format string, v ...interface{}) {
+	a.Logger.Errorf("MeetingReminder", format, v...)
+}
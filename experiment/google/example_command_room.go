@@ -5,33 +5,53 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/evalphobia/bobo-experiment/i18n"
 	"github.com/evalphobia/google-api-go-wrapper/calendar"
+	SDK "google.golang.org/api/calendar/v3"
 
 	"github.com/eure/bobo/command"
 )
 
+const defaultRoomConcurrency = 8
+
 var _ command.CommandTemplate = &RoomCommand{}
 
 type RoomCommand struct {
-	roomIDs []string
+	// ConfigFile is a JSON file of room metadata. (building, floor, capacity and features)
+	// If it's empty, envvar ROOM_CONFIG_FILE is used.
+	ConfigFile string
+	// UseDirectory uses Admin Directory API to get room metadata.
+	UseDirectory bool
+	// Concurrency is max number of parallel requests to Calendar API.
+	Concurrency int
+	// CacheTTL is lifetime of the room list. (default: 1h)
+	CacheTTL time.Duration
+
+	// filters for rooms. name patterns are glob. e.g.) "Room *"
+	IncludeRooms     []string
+	ExcludeRooms     []string
+	IncludeBuildings []string
+	ExcludeBuildings []string
+
+	directory roomDirectory
 }
 
-func (RoomCommand) GetMentionCommand() string {
+func (*RoomCommand) GetMentionCommand() string {
 	return "room"
 }
 
-func (RoomCommand) GetHelp() string {
-	return "Get empty rooms from Google Calendar"
+func (*RoomCommand) GetHelp() string {
+	return "Get empty rooms from Google Calendar [at 15:00] [for 60m] [floor:3] [capacity>=8] [feature:<name>]"
 }
 
-func (RoomCommand) HasHelp() bool {
+func (*RoomCommand) HasHelp() bool {
 	return true
 }
 
-func (RoomCommand) GetRegexp() *regexp.Regexp {
+func (*RoomCommand) GetRegexp() *regexp.Regexp {
 	return nil
 }
 
@@ -44,38 +64,40 @@ func (a *RoomCommand) Exec(d command.CommandData) {
 func (a *RoomCommand) runRoom(d command.CommandData) command.Command {
 	c := command.Command{}
 
-	_, err := getGoogleCalendarClient()
+	sender := getSenderEmail(d)
+	svc, err := getGoogleCalendarService(sender)
 	if err != nil {
-		errMessage := fmt.Sprintf("[ERROR]\t[getGoogleCalendarClient]\t`%s`", err.Error())
+		errMessage := fmt.Sprintf("[ERROR]\t[getGoogleCalendarService]\t`%s`", err.Error())
 		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
 		c.Add(task)
 		return c
 	}
 
-	if len(a.roomIDs) == 0 {
-		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Getting rooms...")).Run()
-		ids, err := fetchAllResourceCalendarIDs()
-		if err != nil {
-			task := command.NewReplyEngineTask(d.Engine, d.Channel, err.Error())
-			c.Add(task)
-			return c
-		}
-		a.roomIDs = ids
-	}
-	if len(a.roomIDs) == 0 {
-		task := command.NewReplyEngineTask(d.Engine, d.Channel, "[ERROR]\t[CalendarList]\t`No valid resources`")
+	now := time.Now().In(getUserLocation(d))
+	q, err := parseRoomQuery(d.TextOther, now)
+	if err != nil {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, err.Error())
 		c.Add(task)
 		return c
 	}
+	if q.HasOption {
+		return a.runRoomQuery(d, svc, q)
+	}
 
-	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Getting room events...")).Run()
-	events, err := fetchEventsOfRooms(a.roomIDs)
+	rooms, err := a.getRooms(d, sender)
 	if err != nil {
 		task := command.NewReplyEngineTask(d.Engine, d.Channel, err.Error())
 		c.Add(task)
 		return c
 	}
+	if len(rooms) == 0 {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, "[ERROR]\t[CalendarList]\t`No valid resources`")
+		c.Add(task)
+		return c
+	}
 
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Getting room events...")).Run()
+	events := fetchEventsOfRooms(svc, rooms, now, a.getConcurrency())
 	sort.Sort(events)
 	msg := events.makeMessage()
 	msg = "```\n" + msg + "\n```"
@@ -84,25 +106,161 @@ func (a *RoomCommand) runRoom(d command.CommandData) command.Command {
 	return c
 }
 
-func fetchAllResourceCalendarIDs() ([]string, error) {
-	const resourceSuffix = "@resource.calendar.google.com"
+// getRooms returns cached rooms, and fetches them when the cache is expired.
+// sender is the email address of the user who accesses Google APIs.
+func (a *RoomCommand) getRooms(d command.CommandData, sender string) ([]RoomInfo, error) {
+	return a.directory.Get(a.getCacheTTL(), func() ([]RoomInfo, error) {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Getting rooms...")).Run()
+		return a.fetchRooms(sender)
+	})
+}
 
-	cli, _ := getGoogleCalendarClient()
+// refreshRooms fetches rooms ignoring the cache.
+func (a *RoomCommand) refreshRooms(sender string) ([]RoomInfo, error) {
+	return a.directory.Refresh(func() ([]RoomInfo, error) {
+		return a.fetchRooms(sender)
+	})
+}
 
-	result := make([]string, 0, 1024)
+func (a *RoomCommand) fetchRooms(sender string) ([]RoomInfo, error) {
+	rooms, err := fetchRoomInfos(sender, a.ConfigFile, a.UseDirectory)
+	if err != nil {
+		return nil, err
+	}
+	return a.getFilter().Filter(rooms), nil
+}
+
+func (a *RoomCommand) getFilter() roomFilter {
+	return roomFilter{
+		IncludeNames:     a.IncludeRooms,
+		ExcludeNames:     a.ExcludeRooms,
+		IncludeBuildings: a.IncludeBuildings,
+		ExcludeBuildings: a.ExcludeBuildings,
+	}
+}
+
+func (a *RoomCommand) getCacheTTL() time.Duration {
+	if a.CacheTTL > 0 {
+		return a.CacheTTL
+	}
+	return defaultRoomCacheTTL
+}
+
+func (a *RoomCommand) getConcurrency() int {
+	if a.Concurrency > 0 {
+		return a.Concurrency
+	}
+	return defaultRoomConcurrency
+}
+
+// runRoomQuery checks rooms are free for the whole requested interval.
+func (a *RoomCommand) runRoomQuery(d command.CommandData, svc *SDK.Service, q roomQuery) command.Command {
+	c := command.Command{}
+
+	allRooms, err := a.getRooms(d, getSenderEmail(d))
+	if err != nil {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, err.Error())
+		c.Add(task)
+		return c
+	}
+
+	rooms := make([]RoomInfo, 0, len(allRooms))
+	ids := make([]string, 0, len(allRooms))
+	for _, r := range allRooms {
+		if q.Match(r) {
+			rooms = append(rooms, r)
+			ids = append(ids, r.ID)
+		}
+	}
+	if len(rooms) == 0 {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("No rooms match the conditions"))
+		c.Add(task)
+		return c
+	}
+
+	span := q.Span()
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Getting room events...")).Run()
+	fb, err := fetchFreeBusy(svc, ids, span.Start, span.End)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[Freebusy]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	results := make([]string, 0, len(rooms)+1)
+	results = append(results, i18n.Message("[Available rooms] %s - %s", formatLocalDateTime(span.Start), formatLocalTime(span.End)))
+	var errLines []string
+	for _, r := range rooms {
+		if reason, ok := fb.Errors[r.ID]; ok {
+			errLines = append(errLines, fmt.Sprintf("[%s]\t\t[ERROR]\t[Freebusy]\t`%s`", r.getName(), reason))
+			continue
+		}
+		if !fb.IsFree(r.ID, span) {
+			continue
+		}
+		results = append(results, formatRoomInfo(r))
+	}
+	if len(results) == 1 {
+		results = append(results, i18n.Message("No rooms available"))
+	}
+	results = append(results, errLines...)
+
+	msg := "```\n" + strings.Join(results, "\n") + "\n```"
+	task := command.NewReplyEngineTask(d.Engine, d.Channel, msg)
+	c.Add(task)
+	return c
+}
+
+func formatRoomInfo(r RoomInfo) string {
+	list := make([]string, 0, 5)
+	list = append(list, fmt.Sprintf("[%s]", r.getName()))
+	if r.Building != "" {
+		list = append(list, r.Building)
+	}
+	if r.Floor != "" {
+		list = append(list, i18n.Message("Floor %s", normalizeFloor(r.Floor)))
+	}
+	if r.Capacity > 0 {
+		list = append(list, i18n.Message("%d people", r.Capacity))
+	}
+	if len(r.Features) != 0 {
+		list = append(list, "("+strings.Join(r.Features, ", ")+")")
+	}
+	return strings.Join(list, "\t")
+}
+
+func fetchAllResourceCalendarIDs(svc *SDK.Service) ([]string, error) {
+	list, err := fetchAllResourceCalendars(svc)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(list))
+	for i, v := range list {
+		result[i] = v.ID
+	}
+	return result, nil
+}
+
+func fetchAllResourceCalendars(svc *SDK.Service) ([]calendar.CalendarEntry, error) {
+	const resourceSuffix = "@resource.calendar.google.com"
+
+	result := make([]calendar.CalendarEntry, 0, 1024)
 	nextPageToken := ""
 	for {
-		resp, err := cli.CalendarListWithOption(calendar.CalendarListOption{
-			PageToken:  nextPageToken,
-			MaxResults: 250,
-		})
+		call := svc.CalendarList.List().MaxResults(250)
+		if nextPageToken != "" {
+			call.PageToken(nextPageToken)
+		}
+		resp, err := call.Do()
 		if err != nil {
 			return nil, fmt.Errorf("[ERROR]\t[CalendarList]\t`%s`", err.Error())
 		}
 
-		for _, v := range resp.List {
+		for _, v := range calendar.NewCalendarEntries(resp.Items) {
 			if strings.HasSuffix(v.ID, resourceSuffix) {
-				result = append(result, v.ID)
+				result = append(result, v)
 			}
 		}
 
@@ -114,39 +272,100 @@ func fetchAllResourceCalendarIDs() ([]string, error) {
 	return result, nil
 }
 
-func fetchEventsOfRooms(ids []string) (RoomEvents, error) {
-	cli, _ := getGoogleCalendarClient()
-	now := time.Now()
-	timeBorder := now.Add(10 * time.Minute)
+// fetchEventsOfRooms fetches current and next events of the rooms in parallel.
+// An error of a room is set into its result, not to abort other rooms.
+func fetchEventsOfRooms(svc *SDK.Service, rooms []RoomInfo, now time.Time, concurrency int) RoomEvents {
+	results := make(RoomEvents, len(rooms))
 
-	results := make([]RoomEvent, 0, len(ids))
-	for _, id := range ids {
-		resp, err := cli.EventListWithOption(id, calendar.EventListOption{
-			TimeMin:      now,
-			SingleEvents: true,
-			OrderBy:      calendar.OrderByStartTime,
-			MaxResults:   1,
-		})
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, room := range rooms {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, room RoomInfo) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			ev, err := fetchEventOfRoom(svc, room, now)
+			if err != nil {
+				ev = RoomEvent{
+					Room:  room.getName(),
+					Error: err.Error(),
+				}
+			}
+			results[i] = ev
+		}(i, room)
+	}
+	wg.Wait()
+	return results
+}
+
+// fetchEventOfRoom returns the room is free or busy, and until when.
+// All of today's events are fetched, because sequential events extend the busy time.
+func fetchEventOfRoom(svc *SDK.Service, room RoomInfo, now time.Time) (RoomEvent, error) {
+	var items []*SDK.Event
+	nextPageToken := ""
+	for {
+		call := svc.Events.List(room.ID).
+			TimeMin(now.Format(time.RFC3339)).
+			TimeMax(truncateDay(now).AddDate(0, 0, 1).Format(time.RFC3339)).
+			SingleEvents(true).
+			OrderBy("startTime").
+			MaxResults(250)
+		if nextPageToken != "" {
+			call.PageToken(nextPageToken)
+		}
+		resp, err := call.Do()
 		if err != nil {
-			return nil, fmt.Errorf("[ERROR]\t[EventList]\t`%s`", err.Error())
+			return RoomEvent{}, fmt.Errorf("[ERROR]\t[EventList]\t`%s`", err.Error())
 		}
-		if len(resp.List) == 0 {
-			continue
+		items = append(items, resp.Items...)
+
+		if resp.NextPageToken == "" {
+			break
 		}
+		nextPageToken = resp.NextPageToken
+	}
 
-		ev := resp.List[0]
-		result := RoomEvent{
-			Room:      ev.Location,
-			Event:     ev.Summary,
-			StartTime: ev.StartTime,
-			EndTime:   ev.EndTime,
+	return newRoomEvent(room.getName(), calendar.NewEvents(items), now), nil
+}
+
+// newRoomEvent returns status of the room from events which end after now.
+func newRoomEvent(name string, list []calendar.Event, now time.Time) RoomEvent {
+	result := RoomEvent{
+		Room:    name,
+		IsEmpty: true,
+	}
+
+	// events are sorted by start time.
+	loc := now.Location()
+	for _, ev := range list {
+		if ev.IsAllDayEvent || !ev.EndTime.After(now) {
+			continue
 		}
-		if ev.StartTime.After(timeBorder) {
-			result.IsEmpty = true
+		ev.StartTime = ev.StartTime.In(loc)
+		ev.EndTime = ev.EndTime.In(loc)
+
+		switch {
+		case result.IsEmpty && result.Event == "":
+			if ev.StartTime.After(now) {
+				// free until the next event.
+				result.Until = ev.StartTime
+				return result
+			}
+			result.IsEmpty = false
+			result.Event = ev.Summary
+			result.StartTime = ev.StartTime
+			result.Until = ev.EndTime
+		case ev.StartTime.After(result.Until):
+			// busy until the end of the sequential events.
+			return result
+		case ev.EndTime.After(result.Until):
+			result.Until = ev.EndTime
 		}
-		results = append(results, result)
 	}
-	return results, nil
+	return result
 }
 
 type RoomEvents []RoomEvent
@@ -159,8 +378,15 @@ func (l RoomEvents) Swap(i, j int) {
 	l[i], l[j] = l[j], l[i]
 }
 
+// available rooms come first, and rooms with errors come last.
 func (l RoomEvents) Less(i, j int) bool {
-	return l[i].Room > l[j].Room
+	if (l[i].Error == "") != (l[j].Error == "") {
+		return l[i].Error == ""
+	}
+	if l[i].IsEmpty != l[j].IsEmpty {
+		return l[i].IsEmpty
+	}
+	return l[i].Room < l[j].Room
 }
 
 func (l RoomEvents) makeMessage() string {
@@ -175,13 +401,22 @@ type RoomEvent struct {
 	Room      string
 	Event     string
 	StartTime time.Time
-	EndTime   time.Time
-	IsEmpty   bool
+	// Until is the end of current events when busy, or the start of next event when free.
+	// It's zero when there are no more events today.
+	Until   time.Time
+	IsEmpty bool
+	// Error is set when events of the room cannot be fetched.
+	Error string
 }
 
 func (e RoomEvent) makeSentence() string {
-	if e.IsEmpty {
+	switch {
+	case e.Error != "":
+		return fmt.Sprintf("[%s]\t\t%s", e.Room, e.Error)
+	case e.IsEmpty && e.Until.IsZero():
 		return i18n.Message("[%s]\t\t(Available)", e.Room)
+	case e.IsEmpty:
+		return i18n.Message("[%s]\t\t(Available) free until %s", e.Room, formatLocalTime(e.Until))
 	}
-	return i18n.Message("[%s]\t\t%s (%s - %s)", e.Room, e.Event, formatLocalDateTime(e.StartTime), formatLocalTime(e.EndTime))
+	return i18n.Message("[%s]\t\t%s (busy until %s)", e.Room, e.Event, formatLocalTime(e.Until))
 }
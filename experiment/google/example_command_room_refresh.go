@@ -0,0 +1,48 @@
+package google
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+var _ command.CommandTemplate = RoomRefreshCommand{}
+
+// RoomRefreshCommand refreshes the room list cache of RoomCommand.
+type RoomRefreshCommand struct {
+	Room *RoomCommand
+}
+
+func (RoomRefreshCommand) GetMentionCommand() string {
+	return "room:refresh"
+}
+
+func (RoomRefreshCommand) GetHelp() string {
+	return "Refresh room list from Google Calendar"
+}
+
+func (RoomRefreshCommand) HasHelp() bool {
+	return true
+}
+
+func (RoomRefreshCommand) GetRegexp() *regexp.Regexp {
+	return nil
+}
+
+func (a RoomRefreshCommand) Exec(d command.CommandData) {
+	if a.Room == nil {
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, "[ERROR]\t[RoomRefreshCommand]\t`RoomCommand is not set`").Run()
+		return
+	}
+
+	rooms, err := a.Room.refreshRooms(getSenderEmail(d))
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[refreshRooms]\t`%s`", err.Error())
+		_ = command.NewReplyEngineTask(d.Engine, d.Channel, errMessage).Run()
+		return
+	}
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Refreshed %d rooms", len(rooms))).Run()
+}
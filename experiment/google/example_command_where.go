@@ -5,26 +5,36 @@ import (
 	"strings"
 	"time"
 
-	"github.com/evalphobia/google-api-go-wrapper/calendar"
-
 	"github.com/eure/bobo/command"
 	"github.com/evalphobia/bobo-experiment/i18n"
+	"github.com/evalphobia/bobo-experiment/slackapi"
 )
 
 var WhereCommand = command.BasicCommandTemplate{
-	Help:           "Get current location from Google Calendar",
+	Help:           "Get current location from Google Calendar [@user|#channel|@usergroup]",
 	MentionCommand: "where",
 	GenerateFn: func(d command.CommandData) command.Command {
 		c := command.Command{}
 
-		calendarCli, err := getGoogleCalendarClient()
+		svc, err := getGoogleCalendarService(getSenderEmail(d))
 		if err != nil {
-			errMessage := fmt.Sprintf("[ERROR]\t[getGoogleCalendarClient]\t`%s`", err.Error())
+			errMessage := fmt.Sprintf("[ERROR]\t[getGoogleCalendarService]\t`%s`", err.Error())
+			task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+			c.Add(task)
+			return c
+		}
+		if err := loadWorkingLocationNames(); err != nil {
+			errMessage := fmt.Sprintf("[ERROR]\t[loadWorkingLocationNames]\t`%s`", err.Error())
 			task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
 			c.Add(task)
 			return c
 		}
 
+		// show members of the channel or usergroup.
+		if typ, id := slackapi.ParseGroupTarget(d.TextOther); typ != slackapi.TargetNone {
+			return runWhereTeam(d, svc, typ, id)
+		}
+
 		// get email address for target calendar
 		email, err := getEmailAddress(d)
 		if err != nil {
@@ -35,12 +45,7 @@ var WhereCommand = command.BasicCommandTemplate{
 		}
 
 		command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Getting location of [%s] ...", email)).Run()
-		list, err := calendarCli.EventListWithOption(email, calendar.EventListOption{
-			TimeMin:      time.Now().Add(-1 * time.Hour),
-			SingleEvents: true,
-			OrderBy:      calendar.OrderByStartTime,
-			MaxResults:   10,
-		})
+		list, err := fetchCurrentEvents(svc, email)
 		if err != nil {
 			errMessage := fmt.Sprintf("[ERROR]\t[EventList]\t`%s`", err.Error())
 			task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
@@ -48,14 +53,15 @@ var WhereCommand = command.BasicCommandTemplate{
 			return c
 		}
 
-		res := getCalendarEvent(list.List)
-		if !res.hasTimeEvent && !res.hasAllDayEvent {
+		now := time.Now().In(getUserLocation(d))
+		res := getCalendarEvent(list, now)
+		if !res.hasTimeEvent && !res.hasAllDayEvent && !res.hasStatus() {
 			task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Somewhere around there"))
 			c.Add(task)
 			return c
 		}
 
-		msg := makeMessage(res)
+		msg := makeMessage(res, now)
 		task := command.NewReplyEngineTask(d.Engine, d.Channel, msg)
 		c.Add(task)
 		return c
@@ -63,19 +69,27 @@ var WhereCommand = command.BasicCommandTemplate{
 }
 
 type eventResult struct {
-	List           []*calendar.Event
-	allDay         *calendar.Event
-	prev           *calendar.Event
-	current        *calendar.Event
-	next           *calendar.Event
+	List           []*calendarEvent
+	allDay         *calendarEvent
+	prev           *calendarEvent
+	current        *calendarEvent
+	next           *calendarEvent
 	hasAllDayEvent bool
 	hasTimeEvent   bool
+
+	// ongoing events of special types.
+	outOfOffice     *calendarEvent
+	focusTime       *calendarEvent
+	workingLocation *calendarEvent
 }
 
-func getCalendarEvent(list []calendar.Event) eventResult {
-	now := time.Now()
-	utcNow := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, time.UTC)
+func (r eventResult) hasStatus() bool {
+	return r.outOfOffice != nil || r.focusTime != nil || r.workingLocation != nil
+}
 
+// getCalendarEvent classifies events around now.
+// Times of events are converted into the location of now.
+func getCalendarEvent(list []calendarEvent, now time.Time) eventResult {
 	result := eventResult{}
 	for _, vv := range list {
 		if !vv.IsStatusConfirmed() {
@@ -83,8 +97,31 @@ func getCalendarEvent(list []calendar.Event) eventResult {
 		}
 		ev := vv // change pointer
 
+		// dates of all-day events are treated as the dates in the location.
+		span := newEventSpan(ev.Event, now.Location())
+		ev.StartTime = span.Start
+		ev.EndTime = span.End
+		isOngoing := !now.Before(ev.StartTime) && now.Before(ev.EndTime)
+
+		switch ev.EventType {
+		case eventTypeOutOfOffice, eventTypeFocusTime, eventTypeWorkingLocation:
+			if !isOngoing {
+				continue
+			}
+			switch ev.EventType {
+			case eventTypeOutOfOffice:
+				result.outOfOffice = &ev
+			case eventTypeFocusTime:
+				result.focusTime = &ev
+			default:
+				// a later one is more specific. (e.g. afternoon in the office)
+				result.workingLocation = &ev
+			}
+			continue
+		}
+
 		if ev.IsAllDayEvent {
-			if ev.StartTime.Before(utcNow) && ev.EndTime.After(utcNow) {
+			if isOngoing {
 				result.allDay = &ev
 				result.hasAllDayEvent = true
 			}
@@ -124,8 +161,19 @@ func getCalendarEvent(list []calendar.Event) eventResult {
 	return result
 }
 
-func makeMessage(r eventResult) string {
+func makeMessage(r eventResult, now time.Time) string {
 	list := make([]string, 0, 10)
+	if r.outOfOffice != nil {
+		list = append(list, i18n.Message("OOO until %s", formatEventUntil(r.outOfOffice, now)))
+	}
+	if r.focusTime != nil {
+		list = append(list, i18n.Message("In focus time until %s", formatEventUntil(r.focusTime, now)))
+	}
+	if r.workingLocation != nil {
+		if name := r.workingLocation.getWorkingLocationName(); name != "" {
+			list = append(list, i18n.Message("Working from %s", name))
+		}
+	}
 	if r.prev != nil {
 		list = append(list, makeSentence(i18n.Message("prev"), r.prev))
 	}
@@ -145,9 +193,38 @@ func makeMessage(r eventResult) string {
 	return strings.Join(list, "\n")
 }
 
-func makeSentence(title string, ev *calendar.Event) string {
+func makeSentence(title string, ev *calendarEvent) string {
 	if ev.Location != "" {
 		return i18n.Message("[%s] | Doing [%s] at [%s] (%s - %s)", title, ev.Summary, ev.Location, formatLocalDateTime(ev.StartTime), formatLocalTime(ev.EndTime))
 	}
 	return i18n.Message("Doing [%s] in somewhere around there (%s - %s)", ev.Summary, formatLocalDateTime(ev.StartTime), formatLocalTime(ev.EndTime))
 }
+
+// formatEventUntil returns the end of the event. e.g.) "16:00", "Friday", "06/03 (Monday)"
+func formatEventUntil(ev *calendarEvent, now time.Time) string {
+	end := ev.EndTime
+	if ev.IsAllDayEvent || (end.Hour() == 0 && end.Minute() == 0) {
+		// until the end of the previous day.
+		return formatDayName(end.AddDate(0, 0, -1), now)
+	}
+	if isSameDay(end, now) {
+		return formatLocalTime(end)
+	}
+	return formatDayName(end, now) + " " + formatLocalTime(end)
+}
+
+// formatDayName returns weekday within a week, or date with weekday.
+func formatDayName(day, now time.Time) string {
+	weekday := i18n.Message(day.Weekday().String())
+	switch {
+	case isSameDay(day, now):
+		return i18n.Message("today")
+	case day.Before(truncateDay(now).AddDate(0, 0, 7)):
+		return weekday
+	}
+	return fmt.Sprintf("%s (%s)", formatLocalDate(day), weekday)
+}
+
+func isSameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
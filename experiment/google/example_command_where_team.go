@@ -0,0 +1,156 @@
+package google
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eure/bobo/command"
+	SDK "google.golang.org/api/calendar/v3"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+	"github.com/evalphobia/bobo-experiment/slackapi"
+)
+
+const (
+	defaultWhereTeamConcurrency = 8
+	maxWhereTeamMembers         = 50
+)
+
+// runWhereTeam shows current status of all members in the channel or usergroup.
+func runWhereTeam(d command.CommandData, svc *SDK.Service, typ slackapi.TargetType, id string) command.Command {
+	c := command.Command{}
+
+	userIDs, err := slackapi.GetMemberIDs(typ, id, maxWhereTeamMembers)
+	if err != nil {
+		errMessage := fmt.Sprintf("[ERROR]\t[GetMemberIDs]\t`%s`", err.Error())
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, errMessage)
+		c.Add(task)
+		return c
+	}
+
+	_ = command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("Getting location of %d members ...", len(userIDs))).Run()
+	now := time.Now().In(getUserLocation(d))
+	list := fetchMemberStatuses(d, svc, userIDs, now)
+	if len(list) == 0 {
+		task := command.NewReplyEngineTask(d.Engine, d.Channel, i18n.Message("No members found"))
+		c.Add(task)
+		return c
+	}
+
+	msg := "```\n" + formatMemberStatuses(list) + "```"
+	task := command.NewReplyEngineTask(d.Engine, d.Channel, msg)
+	c.Add(task)
+	return c
+}
+
+const (
+	memberStatusMeeting = "in a meeting"
+	memberStatusFocus   = "in focus time"
+	memberStatusOOO     = "OOO"
+	memberStatusFree    = "free"
+	memberStatusUnknown = "unknown"
+)
+
+// memberStatus is a current status of a team member.
+type memberStatus struct {
+	Name     string
+	Status   string
+	Location string
+	Until    string
+}
+
+// fetchMemberStatuses fetches current events of the users concurrently.
+// Users without email (e.g. bots) are skipped.
+func fetchMemberStatuses(d command.CommandData, svc *SDK.Service, userIDs []string, now time.Time) []memberStatus {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, defaultWhereTeamConcurrency)
+
+	results := make([]memberStatus, 0, len(userIDs))
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			u, err := d.Engine.GetUserByID(userID)
+			if err != nil || u.Email == "" {
+				return
+			}
+
+			s := memberStatus{
+				Name:   u.Name,
+				Status: memberStatusUnknown,
+			}
+			list, err := fetchCurrentEvents(svc, u.Email)
+			if err == nil {
+				s = newMemberStatus(u.Name, getCalendarEvent(list, now), now)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, s)
+		}(userID)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+func newMemberStatus(name string, r eventResult, now time.Time) memberStatus {
+	s := memberStatus{
+		Name:   name,
+		Status: memberStatusFree,
+	}
+	switch {
+	case r.outOfOffice != nil:
+		s.Status = memberStatusOOO
+		s.Until = formatEventUntil(r.outOfOffice, now)
+	case r.current != nil:
+		s.Status = memberStatusMeeting
+		s.Location = r.current.Location
+		s.Until = formatLocalTime(r.current.EndTime)
+	case r.focusTime != nil:
+		s.Status = memberStatusFocus
+		s.Until = formatEventUntil(r.focusTime, now)
+	default:
+		if r.hasAllDayEvent {
+			s.Location = r.allDay.Location
+		}
+		if r.next != nil {
+			s.Until = formatLocalTime(r.next.StartTime)
+		}
+	}
+
+	if s.Location == "" && r.workingLocation != nil {
+		s.Location = r.workingLocation.getWorkingLocationName()
+	}
+	return s
+}
+
+func formatMemberStatuses(list []memberStatus) string {
+	buf := bytes.Buffer{}
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", i18n.Message("Name"), i18n.Message("Status"), i18n.Message("Location"), i18n.Message("Until"))
+	for _, s := range list {
+		location := s.Location
+		if location == "" {
+			location = "-"
+		}
+		until := s.Until
+		if until == "" {
+			until = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, i18n.Message(s.Status), location, until)
+	}
+	w.Flush()
+	return buf.String()
+}
@@ -0,0 +1,141 @@
+package google
+
+import (
+	"sort"
+	"time"
+
+	SDK "google.golang.org/api/calendar/v3"
+)
+
+// max calendars in a single freebusy query.
+const maxFreeBusyItems = 50
+
+// timeSpan is a time range. End is exclusive.
+type timeSpan struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (s timeSpan) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Overlaps checks both of the spans share any time.
+func (s timeSpan) Overlaps(o timeSpan) bool {
+	return s.Start.Before(o.End) && o.Start.Before(s.End)
+}
+
+// freeBusyResult is busy times of calendars.
+type freeBusyResult struct {
+	Busy map[string][]timeSpan
+	// Errors contains calendars which cannot be checked. (e.g. no permission)
+	Errors map[string]string
+}
+
+// fetchFreeBusy fetches busy times of the calendars between start and end.
+func fetchFreeBusy(svc *SDK.Service, ids []string, start, end time.Time) (freeBusyResult, error) {
+	result := freeBusyResult{
+		Busy:   make(map[string][]timeSpan, len(ids)),
+		Errors: make(map[string]string),
+	}
+
+	for i := 0; i < len(ids); i += maxFreeBusyItems {
+		last := i + maxFreeBusyItems
+		if last > len(ids) {
+			last = len(ids)
+		}
+
+		items := make([]*SDK.FreeBusyRequestItem, 0, last-i)
+		for _, id := range ids[i:last] {
+			items = append(items, &SDK.FreeBusyRequestItem{Id: id})
+		}
+
+		resp, err := svc.Freebusy.Query(&SDK.FreeBusyRequest{
+			TimeMin: start.Format(time.RFC3339),
+			TimeMax: end.Format(time.RFC3339),
+			Items:   items,
+		}).Do()
+		if err != nil {
+			return result, err
+		}
+
+		for id, cal := range resp.Calendars {
+			if len(cal.Errors) != 0 {
+				result.Errors[id] = cal.Errors[0].Reason
+				continue
+			}
+			spans := make([]timeSpan, 0, len(cal.Busy))
+			for _, p := range cal.Busy {
+				s, err1 := time.Parse(time.RFC3339, p.Start)
+				e, err2 := time.Parse(time.RFC3339, p.End)
+				if err1 != nil || err2 != nil {
+					continue
+				}
+				spans = append(spans, timeSpan{Start: s, End: e})
+			}
+			result.Busy[id] = spans
+		}
+	}
+	return result, nil
+}
+
+// IsFree checks the calendar has no busy time within the span.
+func (r freeBusyResult) IsFree(id string, span timeSpan) bool {
+	if _, ok := r.Errors[id]; ok {
+		return false
+	}
+	for _, b := range r.Busy[id] {
+		if b.Overlaps(span) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeTimeSpans merges overlapped spans and returns sorted list.
+func mergeTimeSpans(list []timeSpan) []timeSpan {
+	if len(list) == 0 {
+		return nil
+	}
+
+	sorted := make([]timeSpan, len(list))
+	copy(sorted, list)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	results := []timeSpan{sorted[0]}
+	for _, s := range sorted[1:] {
+		last := &results[len(results)-1]
+		if s.Start.After(last.End) {
+			results = append(results, s)
+			continue
+		}
+		if s.End.After(last.End) {
+			last.End = s.End
+		}
+	}
+	return results
+}
+
+// subtractTimeSpans returns free spans in the window which are not in busy list.
+func subtractTimeSpans(window timeSpan, busy []timeSpan) []timeSpan {
+	var results []timeSpan
+	cur := window.Start
+	for _, b := range mergeTimeSpans(busy) {
+		if !b.End.After(cur) {
+			continue
+		}
+		if !b.Start.Before(window.End) {
+			break
+		}
+		if b.Start.After(cur) {
+			results = append(results, timeSpan{Start: cur, End: b.Start})
+		}
+		cur = b.End
+	}
+	if cur.Before(window.End) {
+		results = append(results, timeSpan{Start: cur, End: window.End})
+	}
+	return results
+}
@@ -0,0 +1,396 @@
+package google
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eure/bobo/command"
+	"github.com/evalphobia/google-api-go-wrapper/config"
+	"golang.org/x/oauth2"
+	googleOAuth "golang.org/x/oauth2/google"
+	admin "google.golang.org/api/admin/directory/v1"
+	SDK "google.golang.org/api/calendar/v3"
+	oauth2API "google.golang.org/api/oauth2/v2"
+	"google.golang.org/api/option"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+	"github.com/evalphobia/bobo-experiment/storage"
+)
+
+// types of Google auth. (envvar GOOGLE_AUTH_TYPE)
+const (
+	// a single OAuth token of GOOGLE_API_OAUTH_TOKEN_FILE for all users. (default)
+	googleAuthTypeOAuth = "oauth"
+	// a service account with domain-wide delegation, which impersonates the requesting user.
+	googleAuthTypeServiceAccount = "service_account"
+	// OAuth tokens of each user, which are authorized by google:auth command.
+	googleAuthTypeUserOAuth = "user_oauth"
+
+	defaultGoogleAuthTokenFile = "google_tokens.json"
+	// state of the consent page expires after this duration.
+	googleAuthStateTTL = 10 * time.Minute
+)
+
+// scopes requested on the consent of each user.
+// email scope is used to check the authorized account is the sender's one.
+var googleUserOAuthScopes = []string{
+	SDK.CalendarScope,
+	admin.AdminDirectoryResourceCalendarReadonlyScope,
+	oauth2API.UserinfoEmailScope,
+}
+
+// googleAuthProvider creates HTTP clients for Google APIs.
+type googleAuthProvider interface {
+	// Client returns a client which accesses Google APIs as the user of the email.
+	// email is empty when the user is unknown. (e.g. scheduled commands)
+	Client(email string, scopes []string) (*http.Client, error)
+}
+
+var googleAuthOnce sync.Once
+var googleAuth googleAuthProvider
+
+func getGoogleAuthProvider() googleAuthProvider {
+	googleAuthOnce.Do(func() {
+		switch os.Getenv("GOOGLE_AUTH_TYPE") {
+		case googleAuthTypeServiceAccount:
+			googleAuth = &serviceAccountAuth{
+				Subject: os.Getenv("GOOGLE_AUTH_SUBJECT"),
+			}
+		case googleAuthTypeUserOAuth:
+			googleAuth = newUserOAuthAuth(os.Getenv("GOOGLE_AUTH_TOKEN_FILE"))
+		default:
+			googleAuth = &sharedOAuthAuth{}
+		}
+	})
+	return googleAuth
+}
+
+// getGoogleCalendarService returns Calendar API service for the user.
+func getGoogleCalendarService(email string) (*SDK.Service, error) {
+	cli, err := getGoogleAuthProvider().Client(email, []string{SDK.CalendarScope})
+	if err != nil {
+		return nil, err
+	}
+	return SDK.NewService(context.Background(), option.WithHTTPClient(cli))
+}
+
+// getGoogleDirectoryService returns Admin Directory API service for the user.
+func getGoogleDirectoryService(email string) (*admin.Service, error) {
+	cli, err := getGoogleAuthProvider().Client(email, []string{admin.AdminDirectoryResourceCalendarReadonlyScope})
+	if err != nil {
+		return nil, err
+	}
+	return admin.NewService(context.Background(), option.WithHTTPClient(cli))
+}
+
+// getSenderEmail returns the sender's email address, or empty string when it's unknown.
+func getSenderEmail(d command.CommandData) string {
+	if d.SenderID == "" {
+		return ""
+	}
+	u, err := d.Engine.GetUserByID(d.SenderID)
+	if err != nil {
+		return ""
+	}
+	return u.Email
+}
+
+// httpClientCache caches HTTP clients which have their own token source.
+type httpClientCache struct {
+	mu      sync.Mutex
+	clients map[string]*http.Client
+}
+
+func (c *httpClientCache) get(email string, scopes []string, create func() (*http.Client, error)) (*http.Client, error) {
+	sorted := append([]string{}, scopes...)
+	sort.Strings(sorted)
+	key := email + "|" + strings.Join(sorted, " ")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cli, ok := c.clients[key]; ok {
+		return cli, nil
+	}
+
+	cli, err := create()
+	if err != nil {
+		return nil, err
+	}
+	if c.clients == nil {
+		c.clients = make(map[string]*http.Client)
+	}
+	c.clients[key] = cli
+	return cli, nil
+}
+
+// sharedOAuthAuth uses a single OAuth token for all users.
+type sharedOAuthAuth struct {
+	cache httpClientCache
+}
+
+func (a *sharedOAuthAuth) Client(email string, scopes []string) (*http.Client, error) {
+	return a.cache.get("", scopes, func() (*http.Client, error) {
+		return config.Config{Scopes: scopes}.Client()
+	})
+}
+
+// serviceAccountAuth uses a service account with domain-wide delegation.
+// Credentials are read by google-api-go-wrapper. (e.g. GOOGLE_APPLICATION_CREDENTIALS)
+type serviceAccountAuth struct {
+	// Subject is impersonated when the user is unknown.
+	Subject string
+
+	cache httpClientCache
+}
+
+func (a *serviceAccountAuth) Client(email string, scopes []string) (*http.Client, error) {
+	if email == "" {
+		email = a.Subject
+	}
+	return a.cache.get(email, scopes, func() (*http.Client, error) {
+		conf, err := config.Config{Scopes: scopes}.JWTConfig()
+		if err != nil {
+			return nil, err
+		}
+		conf.Subject = email
+		return conf.Client(context.Background()), nil
+	})
+}
+
+// userOAuthAuth uses OAuth tokens of each user, which are saved on the file.
+type userOAuthAuth struct {
+	store *storage.JSONFile
+	cache httpClientCache
+
+	mu     sync.Mutex
+	loaded bool
+	tokens map[string]*oauth2.Token // key=email
+
+	stateMu sync.Mutex
+	states  map[string]googleAuthState // key=UserID
+}
+
+// googleAuthState is a state parameter issued for the user.
+type googleAuthState struct {
+	value    string
+	expireAt time.Time
+}
+
+func newUserOAuthAuth(tokenFile string) *userOAuthAuth {
+	if tokenFile == "" {
+		tokenFile = defaultGoogleAuthTokenFile
+	}
+	return &userOAuthAuth{
+		store:  storage.NewJSONFile(tokenFile),
+		tokens: make(map[string]*oauth2.Token),
+		states: make(map[string]googleAuthState),
+	}
+}
+
+func (a *userOAuthAuth) Client(email string, scopes []string) (*http.Client, error) {
+	if email == "" {
+		return nil, errors.New(i18n.Message("Google account is unknown. Run it as a user."))
+	}
+
+	return a.cache.get(email, nil, func() (*http.Client, error) {
+		tok, err := a.getToken(email)
+		switch {
+		case err != nil:
+			return nil, err
+		case tok == nil:
+			return nil, errors.New(i18n.Message("Google account [%s] is not authorized yet. Run `google:auth` first.", email))
+		}
+
+		conf, err := a.oauthConfig()
+		if err != nil {
+			return nil, err
+		}
+		ts := &savingTokenSource{
+			base:  conf.TokenSource(context.Background(), tok),
+			auth:  a,
+			email: email,
+			last:  tok,
+		}
+		return oauth2.NewClient(context.Background(), ts), nil
+	})
+}
+
+// AuthCodeURL returns URL of the consent page with a new state for the user.
+func (a *userOAuthAuth) AuthCodeURL(userID string) (string, error) {
+	conf, err := a.oauthConfig()
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(b)
+
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	a.states[userID] = googleAuthState{
+		value:    state,
+		expireAt: time.Now().Add(googleAuthStateTTL),
+	}
+	return conf.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce), nil
+}
+
+// verifyState checks the state is issued for the user, and it can be used only once.
+func (a *userOAuthAuth) verifyState(userID, state string) bool {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+
+	s, ok := a.states[userID]
+	if !ok {
+		return false
+	}
+	delete(a.states, userID)
+	return time.Now().Before(s.expireAt) && subtle.ConstantTimeCompare([]byte(s.value), []byte(state)) == 1
+}
+
+// Exchange exchanges the code into the token of the user and saves it.
+// The token must be issued for the user's email and the state of the user.
+func (a *userOAuthAuth) Exchange(userID, email, code, state string) error {
+	if !a.verifyState(userID, state) {
+		return errors.New(i18n.Message("Invalid or expired state. Run `google:auth` again."))
+	}
+
+	conf, err := a.oauthConfig()
+	if err != nil {
+		return err
+	}
+	tok, err := conf.Exchange(context.Background(), code)
+	if err != nil {
+		return err
+	}
+
+	authorized, err := fetchTokenEmail(conf, tok)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(authorized, email) {
+		return errors.New(i18n.Message("Authorized Google account [%s] does not match your email [%s]", authorized, email))
+	}
+	if err := a.saveToken(email, tok); err != nil {
+		return err
+	}
+
+	// drop the client which has an old token.
+	a.cache.mu.Lock()
+	defer a.cache.mu.Unlock()
+	for key := range a.cache.clients {
+		if strings.HasPrefix(key, email+"|") {
+			delete(a.cache.clients, key)
+		}
+	}
+	return nil
+}
+
+// fetchTokenEmail returns the verified email address of the token.
+func fetchTokenEmail(conf *oauth2.Config, tok *oauth2.Token) (string, error) {
+	ctx := context.Background()
+	svc, err := oauth2API.NewService(ctx, option.WithTokenSource(conf.TokenSource(ctx, tok)))
+	if err != nil {
+		return "", err
+	}
+	info, err := svc.Userinfo.Get().Do()
+	switch {
+	case err != nil:
+		return "", err
+	case info.Email == "",
+		info.VerifiedEmail == nil || !*info.VerifiedEmail:
+		return "", errors.New(i18n.Message("Verified email address of the Google account is not found"))
+	}
+	return info.Email, nil
+}
+
+// oauthConfig uses the same envvars as google-api-go-wrapper.
+func (a *userOAuthAuth) oauthConfig() (*oauth2.Config, error) {
+	if path := os.Getenv("GOOGLE_API_OAUTH_CREDENTIALS"); path != "" {
+		byt, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return googleOAuth.ConfigFromJSON(byt, googleUserOAuthScopes...)
+	}
+	return &oauth2.Config{
+		ClientID:     os.Getenv("GOOGLE_API_OAUTH_CLIENT_ID"),
+		ClientSecret: os.Getenv("GOOGLE_API_OAUTH_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("GOOGLE_API_OAUTH_REDIRECT_URL"),
+		Scopes:       googleUserOAuthScopes,
+		Endpoint:     googleOAuth.Endpoint,
+	}, nil
+}
+
+func (a *userOAuthAuth) getToken(email string) (*oauth2.Token, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	return a.tokens[email], nil
+}
+
+func (a *userOAuthAuth) saveToken(email string, tok *oauth2.Token) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.load(); err != nil {
+		return err
+	}
+	a.tokens[email] = tok
+	return a.store.Save(a.tokens)
+}
+
+// load reads tokens from the file only once.
+func (a *userOAuthAuth) load() error {
+	if a.loaded {
+		return nil
+	}
+	if err := a.store.Load(&a.tokens); err != nil {
+		return err
+	}
+	if a.tokens == nil {
+		a.tokens = make(map[string]*oauth2.Token)
+	}
+	a.loaded = true
+	return nil
+}
+
+// savingTokenSource saves the token when it's refreshed.
+type savingTokenSource struct {
+	base  oauth2.TokenSource
+	auth  *userOAuthAuth
+	email string
+
+	mu   sync.Mutex
+	last *oauth2.Token
+}
+
+func (s *savingTokenSource) Token() (*oauth2.Token, error) {
+	tok, err := s.base.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil || s.last.AccessToken != tok.AccessToken {
+		s.last = tok
+		if err := s.auth.saveToken(s.email, tok); err != nil {
+			return nil, err
+		}
+	}
+	return tok, nil
+}
@@ -0,0 +1,264 @@
+package google
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RoomInfo is metadata of a meeting room.
+type RoomInfo struct {
+	ID          string   `json:"id"` // resource calendar id (email)
+	Name        string   `json:"name"`
+	DisplayName string   `json:"display_name"` // short name for messages
+	Building    string   `json:"building"`
+	Floor       string   `json:"floor"`
+	Capacity    int      `json:"capacity"`
+	Features    []string `json:"features"`
+}
+
+func (r RoomInfo) getName() string {
+	if r.DisplayName != "" {
+		return r.DisplayName
+	}
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.ID
+}
+
+// HasFeature checks the room has the feature. (case-insensitive)
+func (r RoomInfo) HasFeature(name string) bool {
+	for _, f := range r.Features {
+		if strings.EqualFold(f, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchRoomInfos returns rooms from the config file, Admin Directory API or calendar list.
+// APIs are accessed as the user of the email.
+//
+// - configFile: JSON file of []RoomInfo.
+// - useDirectory: use Admin Directory resources API, which needs admin privileges.
+// - otherwise: use resource calendars in the calendar list, which have only names.
+func fetchRoomInfos(email, configFile string, useDirectory bool) ([]RoomInfo, error) {
+	if configFile == "" {
+		configFile = os.Getenv("ROOM_CONFIG_FILE")
+	}
+
+	var list []RoomInfo
+	var err error
+	switch {
+	case configFile != "":
+		list, err = loadRoomInfosFromFile(configFile)
+	case useDirectory:
+		list, err = fetchRoomInfosFromDirectory(email)
+	default:
+		list, err = fetchRoomInfosFromCalendarList(email)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].getName() < list[j].getName()
+	})
+	return list, nil
+}
+
+func loadRoomInfosFromFile(filePath string) ([]RoomInfo, error) {
+	byt, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR]\t[loadRoomInfosFromFile]\t`%s`", err.Error())
+	}
+
+	var list []RoomInfo
+	if err := json.Unmarshal(byt, &list); err != nil {
+		return nil, fmt.Errorf("[ERROR]\t[loadRoomInfosFromFile]\t`%s`", err.Error())
+	}
+	return list, nil
+}
+
+func fetchRoomInfosFromCalendarList(email string) ([]RoomInfo, error) {
+	svc, err := getGoogleCalendarService(email)
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR]\t[getGoogleCalendarService]\t`%s`", err.Error())
+	}
+
+	entries, err := fetchAllResourceCalendars(svc)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]RoomInfo, len(entries))
+	for i, v := range entries {
+		list[i] = RoomInfo{
+			ID:   v.ID,
+			Name: v.Summary,
+		}
+	}
+	return list, nil
+}
+
+func fetchRoomInfosFromDirectory(email string) ([]RoomInfo, error) {
+	svc, err := getGoogleDirectoryService(email)
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR]\t[getGoogleDirectoryService]\t`%s`", err.Error())
+	}
+
+	list := make([]RoomInfo, 0, 256)
+	nextPageToken := ""
+	for {
+		call := svc.Resources.Calendars.List("my_customer").MaxResults(500)
+		if nextPageToken != "" {
+			call.PageToken(nextPageToken)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("[ERROR]\t[Resources.Calendars.List]\t`%s`", err.Error())
+		}
+
+		for _, v := range resp.Items {
+			name := v.ResourceName
+			if v.GeneratedResourceName != "" {
+				name = v.GeneratedResourceName
+			}
+			list = append(list, RoomInfo{
+				ID:       v.ResourceEmail,
+				Name:     name,
+				Building: v.BuildingId,
+				Floor:    v.FloorName,
+				Capacity: int(v.Capacity),
+				Features: parseDirectoryFeatures(v.FeatureInstances),
+			})
+		}
+
+		if resp.NextPageToken == "" {
+			break
+		}
+		nextPageToken = resp.NextPageToken
+	}
+	return list, nil
+}
+
+// parseDirectoryFeatures parses featureInstances of the resource.
+// e.g.) [{"feature": {"name": "Projector"}}]
+func parseDirectoryFeatures(v interface{}) []string {
+	byt, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	var instances []struct {
+		Feature struct {
+			Name string `json:"name"`
+		} `json:"feature"`
+	}
+	if err := json.Unmarshal(byt, &instances); err != nil {
+		return nil
+	}
+
+	results := make([]string, 0, len(instances))
+	for _, f := range instances {
+		if f.Feature.Name != "" {
+			results = append(results, f.Feature.Name)
+		}
+	}
+	return results
+}
+
+const defaultRoomCacheTTL = time.Hour
+
+// roomDirectory is a cache of rooms which is safe for concurrent use.
+type roomDirectory struct {
+	mu        sync.RWMutex
+	rooms     []RoomInfo
+	fetchedAt time.Time
+}
+
+// Get returns cached rooms, and fetches rooms when the cache is expired.
+func (d *roomDirectory) Get(ttl time.Duration, fetch func() ([]RoomInfo, error)) ([]RoomInfo, error) {
+	d.mu.RLock()
+	rooms, fetchedAt := d.rooms, d.fetchedAt
+	d.mu.RUnlock()
+	if len(rooms) != 0 && time.Since(fetchedAt) < ttl {
+		return rooms, nil
+	}
+	return d.Refresh(fetch)
+}
+
+// Refresh fetches rooms and replaces the cache.
+func (d *roomDirectory) Refresh(fetch func() ([]RoomInfo, error)) ([]RoomInfo, error) {
+	rooms, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rooms = rooms
+	d.fetchedAt = time.Now()
+	return rooms, nil
+}
+
+// roomFilter includes or excludes rooms by name pattern or building.
+// Name patterns are glob. e.g.) "Room *", "*(Phone booth)"
+type roomFilter struct {
+	IncludeNames     []string
+	ExcludeNames     []string
+	IncludeBuildings []string
+	ExcludeBuildings []string
+}
+
+func (f roomFilter) Filter(list []RoomInfo) []RoomInfo {
+	results := make([]RoomInfo, 0, len(list))
+	for _, r := range list {
+		if f.Match(r) {
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+func (f roomFilter) Match(r RoomInfo) bool {
+	switch {
+	case len(f.IncludeNames) != 0 && !matchRoomName(f.IncludeNames, r),
+		len(f.IncludeBuildings) != 0 && !containsFold(f.IncludeBuildings, r.Building),
+		matchRoomName(f.ExcludeNames, r),
+		containsFold(f.ExcludeBuildings, r.Building):
+		return false
+	}
+	return true
+}
+
+func matchRoomName(patterns []string, r RoomInfo) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		for _, name := range []string{r.Name, r.DisplayName} {
+			if name == "" {
+				continue
+			}
+			if ok, _ := path.Match(p, strings.ToLower(name)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
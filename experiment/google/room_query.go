@@ -0,0 +1,123 @@
+package google
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evalphobia/bobo-experiment/i18n"
+)
+
+const defaultRoomDuration = 30 * time.Minute
+
+// roomQuery is parsed arguments of room command.
+// e.g.) "at 15:00 for 60m floor:3 capacity>=8"
+type roomQuery struct {
+	At          time.Time
+	Duration    time.Duration
+	Building    string
+	Floor       string
+	MinCapacity int
+	Features    []string
+
+	// HasOption is true when any option is set.
+	HasOption bool
+}
+
+// Span returns the requested interval.
+func (q roomQuery) Span() timeSpan {
+	return timeSpan{Start: q.At, End: q.At.Add(q.Duration)}
+}
+
+// Match checks the room satisfies the conditions.
+func (q roomQuery) Match(r RoomInfo) bool {
+	switch {
+	case q.Building != "" && !strings.EqualFold(r.Building, q.Building),
+		q.Floor != "" && !strings.EqualFold(normalizeFloor(r.Floor), normalizeFloor(q.Floor)),
+		q.MinCapacity > 0 && r.Capacity < q.MinCapacity:
+		return false
+	}
+	for _, f := range q.Features {
+		if !r.HasFeature(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeFloor converts "3F", "3階" and "3" into "3".
+func normalizeFloor(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(s, "階")
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "F"), "f")
+	return s
+}
+
+func parseRoomQuery(text string, now time.Time) (roomQuery, error) {
+	q := roomQuery{
+		At:       now,
+		Duration: defaultRoomDuration,
+	}
+
+	day := truncateDay(now)
+	hasDay := false
+	var clock time.Duration
+	hasClock := false
+
+	words := strings.Fields(text)
+	for i := 0; i < len(words); i++ {
+		w := strings.ToLower(words[i])
+		q.HasOption = true
+		switch {
+		case w == "at" && i+1 < len(words):
+			i++
+			dur, ok := parseClockText(words[i])
+			if !ok {
+				return q, errors.New(i18n.Message("Invalid time format: [%s]", words[i]))
+			}
+			clock = dur
+			hasClock = true
+		case w == "for" && i+1 < len(words):
+			i++
+			dur, ok := parseMeetDuration(words[i])
+			if !ok || dur <= 0 {
+				return q, errors.New(i18n.Message("Invalid duration: [%s]", words[i]))
+			}
+			q.Duration = dur
+		case strings.HasPrefix(w, "floor:"):
+			q.Floor = strings.TrimPrefix(w, "floor:")
+		case strings.HasPrefix(w, "building:"):
+			q.Building = words[i][len("building:"):]
+		case strings.HasPrefix(w, "feature:"):
+			q.Features = append(q.Features, words[i][len("feature:"):])
+		case strings.HasPrefix(w, "capacity>="), strings.HasPrefix(w, "cap>="):
+			n, err := strconv.Atoi(w[strings.Index(w, ">=")+2:])
+			if err != nil || n < 1 {
+				return q, errors.New(i18n.Message("Invalid capacity: [%s]", words[i]))
+			}
+			q.MinCapacity = n
+		default:
+			if dur, ok := parseClockText(w); ok {
+				clock = dur
+				hasClock = true
+				continue
+			}
+			if dt, err := parseAgendaDay(w, day); err == nil {
+				day = dt
+				hasDay = true
+				continue
+			}
+			return q, errors.New(i18n.Message("Unknown option: [%s]", words[i]))
+		}
+	}
+
+	switch {
+	case hasClock:
+		q.At = atClock(day, clock)
+	case hasDay:
+		// the start of working hours on the day.
+		q.At = atClock(day, parseClock("", defaultMeetWorkStart))
+	}
+	return q, nil
+}
@@ -0,0 +1,83 @@
+package google
+
+import (
+	"sync"
+	"time"
+
+	"github.com/eure/bobo/command"
+
+	"github.com/evalphobia/bobo-experiment/slackapi"
+)
+
+// timezone of users are refreshed after this duration, to follow changes on Slack profile.
+const userLocationTTL = 24 * time.Hour
+
+type userLocation struct {
+	loc      *time.Location
+	expireAt time.Time
+}
+
+var userLocationMu sync.RWMutex
+var userLocations = make(map[string]userLocation) // key=UserID
+
+// getUserLocation returns the timezone of the sender.
+func getUserLocation(d command.CommandData) *time.Location {
+	return getLocationByUserID(d.SenderID, getSenderEmail(d))
+}
+
+// getLocationByUserID returns the timezone of the user.
+// It's taken from Slack profile, or the settings of the user's calendar.
+// Local timezone is used when both are not available.
+func getLocationByUserID(userID, email string) *time.Location {
+	userLocationMu.RLock()
+	cached, ok := userLocations[userID]
+	userLocationMu.RUnlock()
+	if ok && time.Now().Before(cached.expireAt) {
+		return cached.loc
+	}
+
+	loc, err := fetchUserLocation(userID, email)
+	switch {
+	case err != nil && ok:
+		// use the expired one, and retry next time.
+		return cached.loc
+	case err != nil:
+		// do not cache to retry next time.
+		return time.Local
+	}
+
+	userLocationMu.Lock()
+	defer userLocationMu.Unlock()
+	userLocations[userID] = userLocation{
+		loc:      loc,
+		expireAt: time.Now().Add(userLocationTTL),
+	}
+	return loc
+}
+
+func fetchUserLocation(userID, email string) (*time.Location, error) {
+	tz, err := slackapi.GetUserTimezone(userID)
+	if err != nil || tz == "" {
+		tz, err = fetchCalendarTimezone(email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tz == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(tz)
+}
+
+// fetchCalendarTimezone returns the timezone in the settings of the user's calendar.
+func fetchCalendarTimezone(email string) (string, error) {
+	svc, err := getGoogleCalendarService(email)
+	if err != nil {
+		return "", err
+	}
+	cal, err := svc.Calendars.Get(email).Do()
+	if err != nil {
+		return "", err
+	}
+	return cal.TimeZone, nil
+}
@@ -1,14 +1,18 @@
 module github.com/evalphobia/bobo-experiment
 
 require (
+	github.com/aws/aws-sdk-go v1.26.8
 	github.com/eure/bobo v0.0.1
 	github.com/evalphobia/aws-sdk-go-wrapper v1.10.0
 	github.com/evalphobia/awscost v0.1.0
 	github.com/evalphobia/go-face-plusplus v0.1.0
 	github.com/evalphobia/google-api-go-wrapper v0.8.3
 	github.com/evalphobia/httpwrapper v0.2.1
+	github.com/nlopes/slack v0.6.1-0.20191106133607-d06c2a2b3249
 	github.com/tmc/langchaingo v0.0.0-20230625234550-7ea734523e39
+	golang.org/x/oauth2 v0.8.0
 	golang.org/x/text v0.9.0
+	google.golang.org/api v0.122.0
 )
 
 require (
@@ -17,7 +21,6 @@ require (
 	github.com/Masterminds/goutils v1.1.1 // indirect
 	github.com/Masterminds/semver/v3 v3.2.0 // indirect
 	github.com/Masterminds/sprig/v3 v3.2.3 // indirect
-	github.com/aws/aws-sdk-go v1.26.8 // indirect
 	github.com/dlclark/regexp2 v1.8.1 // indirect
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
@@ -32,7 +35,6 @@ require (
 	github.com/mitchellh/copystructure v1.0.0 // indirect
 	github.com/mitchellh/reflectwalk v1.0.0 // indirect
 	github.com/nbio/st v0.0.0-20140626010706-e9e8d9816f32 // indirect
-	github.com/nlopes/slack v0.6.1-0.20191106133607-d06c2a2b3249 // indirect
 	github.com/pkg/errors v0.8.0 // indirect
 	github.com/pkoukk/tiktoken-go v0.1.2 // indirect
 	github.com/shopspring/decimal v1.2.0 // indirect
@@ -42,9 +44,7 @@ require (
 	golang.org/x/crypto v0.7.0 // indirect
 	golang.org/x/exp v0.0.0-20220303212507-bbda1eaf7a17 // indirect
 	golang.org/x/net v0.10.0 // indirect
-	golang.org/x/oauth2 v0.8.0 // indirect
 	golang.org/x/sys v0.8.0 // indirect
-	google.golang.org/api v0.122.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
 	google.golang.org/grpc v1.55.0 // indirect
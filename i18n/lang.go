@@ -8,6 +8,7 @@ import (
 )
 
 var defaultPrinter = message.NewPrinter(language.English)
+var defaultLanguage = "en"
 
 func init() {
 	for _, lang := range langs {
@@ -23,12 +24,19 @@ func setDefaultLanguage() {
 	switch os.Getenv("BOBO_LANG") {
 	case "ja":
 		tag = language.Japanese
+		defaultLanguage = "ja"
 	default:
 		tag = language.English
+		defaultLanguage = "en"
 	}
 	defaultPrinter = message.NewPrinter(tag)
 }
 
+// Language returns ISO 639-1 code of the bot language.
+func Language() string {
+	return defaultLanguage
+}
+
 func Message(key string, args ...interface{}) string {
 	return defaultPrinter.Sprintf(key, args...)
 }
@@ -42,18 +50,81 @@ var langs = []translation{
 	{Key: "Invalid date format: [%s]", List: []translationData{
 		{language.Japanese, "日付の指定が不正です: [%s]"},
 	}},
+	{Key: "Invalid option: [%s] `%s`", List: []translationData{
+		{language.Japanese, "オプションの指定が不正です: [%s] `%s`"},
+	}},
 	{Key: "Getting costs on [%s]...", List: []translationData{
 		{language.Japanese, "[%s] のコストを取得中..."},
 	}},
 	{Key: "[AWS Estimate Costs] %s", List: []translationData{
 		{language.Japanese, "[AWS概算コスト] %s"},
 	}},
+	{Key: "Group by: %s / Source: %s", List: []translationData{
+		{language.Japanese, "集計単位: %s / 取得元: %s"},
+	}},
+	{Key: "- (Other %d items):\t%s\t(%.1f%%)", List: []translationData{
+		{language.Japanese, "- (その他 %d 件):\t%s\t(%.1f%%)"},
+	}},
+	{Key: "Comparing costs of [%s] and [%s]...", List: []translationData{
+		{language.Japanese, "[%s] と [%s] のコストを比較中..."},
+	}},
+	{Key: "[AWS Cost Changes] %s vs %s", List: []translationData{
+		{language.Japanese, "[AWSコスト変動] %s / %s"},
+	}},
+	{Key: "No significant changes", List: []translationData{
+		{language.Japanese, "大きな変動はありません"},
+	}},
+	{Key: "new", List: []translationData{
+		{language.Japanese, "新規"},
+	}},
+	{Key: "Getting cost forecast of [%s]...", List: []translationData{
+		{language.Japanese, "[%s] のコスト予測を取得中..."},
+	}},
+	{Key: "No budget is configured. Set AWSCOST_BUDGET_FILE", List: []translationData{
+		{language.Japanese, "予算が設定されていません。AWSCOST_BUDGET_FILE を設定してください"},
+	}},
+	{Key: "[AWS Cost Forecast] %s (as of %s)", List: []translationData{
+		{language.Japanese, "[AWSコスト予測] %s (%s 時点)"},
+	}},
+	{Key: ":warning: Projected spend exceeds the budget!", List: []translationData{
+		{language.Japanese, ":warning: 予測コストが予算を超えています!"},
+	}},
+	{Key: "  Actual (MTD):\t%s", List: []translationData{
+		{language.Japanese, "  当月実績:\t%s"},
+	}},
+	{Key: "  Actual (MTD):\t%s\t(%.1f%% of budget)", List: []translationData{
+		{language.Japanese, "  当月実績:\t%s\t(予算の %.1f%%)"},
+	}},
+	{Key: "  Projected:\t%s", List: []translationData{
+		{language.Japanese, "  月末予測:\t%s"},
+	}},
+	{Key: "  Projected:\t%s\t(%.1f%% of budget)", List: []translationData{
+		{language.Japanese, "  月末予測:\t%s\t(予算の %.1f%%)"},
+	}},
+	{Key: "  Budget:\t%s", List: []translationData{
+		{language.Japanese, "  予算:\t%s"},
+	}},
+	{Key: "  Burn rate:\t%s/day (%d/%d days)", List: []translationData{
+		{language.Japanese, "  消化ペース:\t%s/日 (%d/%d 日)"},
+	}},
+	{Key: "Checking cost anomalies on [%s]...", List: []translationData{
+		{language.Japanese, "[%s] のコスト異常を確認中..."},
+	}},
+	{Key: "[AWS Cost Anomaly] %s", List: []translationData{
+		{language.Japanese, "[AWSコスト異常] %s"},
+	}},
+	{Key: "No cost anomalies", List: []translationData{
+		{language.Japanese, "コスト異常はありません"},
+	}},
+	{Key: "- %s:\t%s\t(baseline %s, %s)", List: []translationData{
+		{language.Japanese, "- %s:\t%s\t(平常時 %s, %s)"},
+	}},
 	// Merge
 	{Key: "No!", List: []translationData{
 		{language.Japanese, "だが断る。"},
 	}},
-	{Key: "Set Two URLs", List: []translationData{
-		{language.Japanese, "URLを2つセットしてください"},
+	{Key: "Set two image URLs or attach two images", List: []translationData{
+		{language.Japanese, "画像のURLを2つ指定するか、画像を2枚添付してください"},
 	}},
 	{Key: "Invalid URL. It must begin with [http/https]", List: []translationData{
 		{language.Japanese, "[http/https] で始まるURLを指定してください"},
@@ -61,6 +132,144 @@ var langs = []translation{
 	{Key: "Merging...", List: []translationData{
 		{language.Japanese, "マージ中..."},
 	}},
+	{Key: "No face is detected in image %d", List: []translationData{
+		{language.Japanese, "画像 %d から顔が見つかりませんでした"},
+	}},
+	{Key: "Face #%d is not found in image %d. It has %d faces", List: []translationData{
+		{language.Japanese, "画像 %[2]d に顔 #%[1]d はありません。顔は %[3]d 人です"},
+	}},
+	{Key: "Found %d faces in image %d. Choose one by `merge:face <number>`", List: []translationData{
+		{language.Japanese, "画像 %[2]d に %[1]d 人の顔が見つかりました。`merge:face <番号>` で選んでください"},
+	}},
+	{Key: "Set a face number", List: []translationData{
+		{language.Japanese, "顔の番号を指定してください"},
+	}},
+	{Key: "No merge is waiting for face selection", List: []translationData{
+		{language.Japanese, "顔の選択待ちのマージはありません"},
+	}},
+	// Face
+	{Key: "Set an image URL or attach an image", List: []translationData{
+		{language.Japanese, "画像のURLを指定するか、画像を添付してください"},
+	}},
+	{Key: "Unsupported image type [%s]. Use JPEG or PNG", List: []translationData{
+		{language.Japanese, "[%s] は対応していない画像形式です。JPEG か PNG を使ってください"},
+	}},
+	{Key: "Image is too large. It must be less than %dMB", List: []translationData{
+		{language.Japanese, "画像が大きすぎます。%dMB 以下にしてください"},
+	}},
+	{Key: "Image size must be between %dx%d and %dx%d pixels", List: []translationData{
+		{language.Japanese, "画像サイズは %dx%d から %dx%d ピクセルの範囲にしてください"},
+	}},
+	{Key: "No face is detected", List: []translationData{
+		{language.Japanese, "顔が見つかりませんでした"},
+	}},
+	{Key: "Detected %d faces", List: []translationData{
+		{language.Japanese, "%d 人の顔を検出しました"},
+	}},
+	{Key: "male", List: []translationData{
+		{language.Japanese, "男性"},
+	}},
+	{Key: "female", List: []translationData{
+		{language.Japanese, "女性"},
+	}},
+	{Key: "%d years old", List: []translationData{
+		{language.Japanese, "%d 歳"},
+	}},
+	{Key: "smiling", List: []translationData{
+		{language.Japanese, "笑顔"},
+	}},
+	{Key: "no smile", List: []translationData{
+		{language.Japanese, "真顔"},
+	}},
+	{Key: "neutral", List: []translationData{
+		{language.Japanese, "平静"},
+	}},
+	{Key: "happiness", List: []translationData{
+		{language.Japanese, "喜び"},
+	}},
+	{Key: "surprise", List: []translationData{
+		{language.Japanese, "驚き"},
+	}},
+	{Key: "sadness", List: []translationData{
+		{language.Japanese, "悲しみ"},
+	}},
+	{Key: "anger", List: []translationData{
+		{language.Japanese, "怒り"},
+	}},
+	{Key: "disgust", List: []translationData{
+		{language.Japanese, "嫌悪"},
+	}},
+	{Key: "fear", List: []translationData{
+		{language.Japanese, "恐れ"},
+	}},
+	{Key: "beauty(F/M)", List: []translationData{
+		{language.Japanese, "美しさ(女性/男性視点)"},
+	}},
+	{Key: "headpose(yaw/pitch/roll)", List: []translationData{
+		{language.Japanese, "顔の向き(ヨー/ピッチ/ロール)"},
+	}},
+
+	// Merge Target
+	{Key: "Merge target [%s] is not found", List: []translationData{
+		{language.Japanese, "マージ対象 [%s] は見つかりません"},
+	}},
+	{Key: "Merge target [%s] has no valid image", List: []translationData{
+		{language.Japanese, "マージ対象 [%s] に有効な画像がありません"},
+	}},
+	{Key: "Invalid target name. Use [a-z0-9_]", List: []translationData{
+		{language.Japanese, "対象名が不正です。[a-z0-9_] を使ってください"},
+	}},
+	{Key: "Usage: `merge-target:add <name> <image url>`", List: []translationData{
+		{language.Japanese, "使い方: `merge-target:add <名前> <画像URL>`"},
+	}},
+	{Key: "Added the image to merge target [%s]. Run `merge-%s <image>`. (%d images)", List: []translationData{
+		{language.Japanese, "マージ対象 [%s] に画像を追加しました。`merge-%s <画像>` で実行できます (%d 枚)"},
+	}},
+	// FaceSet
+	{Key: "Usage: `face:register me --agree <image>`", List: []translationData{
+		{language.Japanese, "使い方: `face:register me --agree <画像>`"},
+	}},
+	{Key: "You can register only your own face", List: []translationData{
+		{language.Japanese, "登録できるのは自分の顔だけです"},
+	}},
+	{Key: "Your face image is sent to Face++ and the face data is stored to identify you by `face:who`. You can delete it anytime by `face:forget me`. If you agree, run `face:register me --agree <image>`", List: []translationData{
+		{language.Japanese, "顔画像は Face++ に送信され、`face:who` であなたを識別するために顔データが保存されます。`face:forget me` でいつでも削除できます。同意する場合は `face:register me --agree <画像>` を実行してください"},
+	}},
+	{Key: "Use an image which has only your face. (detected: %d)", List: []translationData{
+		{language.Japanese, "あなたの顔だけが写った画像を使ってください (検出数: %d)"},
+	}},
+	{Key: "Registered your face. (%d images)", List: []translationData{
+		{language.Japanese, "顔を登録しました (%d 枚)"},
+	}},
+	{Key: "You can delete only your own face. Run `face:forget me`", List: []translationData{
+		{language.Japanese, "削除できるのは自分の顔だけです。`face:forget me` を実行してください"},
+	}},
+	{Key: "Your face is not registered", List: []translationData{
+		{language.Japanese, "顔は登録されていません"},
+	}},
+	{Key: "Deleted your faces", List: []translationData{
+		{language.Japanese, "顔の登録を削除しました"},
+	}},
+
+	// Face++ Usage
+	{Key: "Daily quota of Face++ is exceeded. (%d/%d)", List: []translationData{
+		{language.Japanese, "本日の Face++ の利用上限に達しました (%d/%d)"},
+	}},
+	{Key: "Your daily quota of Face++ is exceeded. (%d/%d)", List: []translationData{
+		{language.Japanese, "あなたの本日の Face++ の利用上限に達しました (%d/%d)"},
+	}},
+	{Key: "Face++ usage of [%s]", List: []translationData{
+		{language.Japanese, "[%s] の Face++ 利用状況"},
+	}},
+	{Key: "Total", List: []translationData{
+		{language.Japanese, "合計"},
+	}},
+	{Key: "You", List: []translationData{
+		{language.Japanese, "あなた"},
+	}},
+	{Key: "Cache hits", List: []translationData{
+		{language.Japanese, "キャッシュヒット"},
+	}},
 
 	// Calendar
 	{Key: "[AllDay] [%s - %s]", List: []translationData{
@@ -72,6 +281,188 @@ var langs = []translation{
 	{Key: "Target format is invalid, use @mention or correct email address.", List: []translationData{
 		{language.Japanese, "@メンション か 正しいメールアドレス を指定してください"},
 	}},
+	{Key: "[AllDay]", List: []translationData{
+		{language.Japanese, "【終日】"},
+	}},
+	{Key: "(day %d/%d)", List: []translationData{
+		{language.Japanese, "(%d/%d 日目)"},
+	}},
+	{Key: "No events", List: []translationData{
+		{language.Japanese, "予定はありません"},
+	}},
+	{Key: "Sunday", List: []translationData{
+		{language.Japanese, "日"},
+	}},
+	{Key: "Monday", List: []translationData{
+		{language.Japanese, "月"},
+	}},
+	{Key: "Tuesday", List: []translationData{
+		{language.Japanese, "火"},
+	}},
+	{Key: "Wednesday", List: []translationData{
+		{language.Japanese, "水"},
+	}},
+	{Key: "Thursday", List: []translationData{
+		{language.Japanese, "木"},
+	}},
+	{Key: "Friday", List: []translationData{
+		{language.Japanese, "金"},
+	}},
+	{Key: "Saturday", List: []translationData{
+		{language.Japanese, "土"},
+	}},
+
+	// Meet
+	{Key: "Finding free slots of [%s] ...", List: []translationData{
+		{language.Japanese, "[%s] の空き時間を確認中..."},
+	}},
+	{Key: "Cannot get free/busy of [%s]: %s", List: []translationData{
+		{language.Japanese, "[%s] の空き時間を取得できませんでした: %s"},
+	}},
+	{Key: "No common free slots", List: []translationData{
+		{language.Japanese, "全員の空いている時間がありません"},
+	}},
+	{Key: "[Meeting Candidates] %s", List: []translationData{
+		{language.Japanese, "【ミーティング候補】 %s"},
+	}},
+	{Key: "(free until %s)", List: []translationData{
+		{language.Japanese, "(%s まで空き)"},
+	}},
+	{Key: "Invalid duration: [%s]", List: []translationData{
+		{language.Japanese, "時間の指定が不正です: [%s]"},
+	}},
+	{Key: "%d min", List: []translationData{
+		{language.Japanese, "%d分"},
+	}},
+	{Key: "%d hour", List: []translationData{
+		{language.Japanese, "%d時間"},
+	}},
+	{Key: "%d hour %d min", List: []translationData{
+		{language.Japanese, "%d時間%d分"},
+	}},
+
+	// Room
+	{Key: "Invalid time format: [%s]", List: []translationData{
+		{language.Japanese, "時刻の指定が不正です: [%s]"},
+	}},
+	{Key: "Invalid capacity: [%s]", List: []translationData{
+		{language.Japanese, "人数の指定が不正です: [%s]"},
+	}},
+	{Key: "Unknown option: [%s]", List: []translationData{
+		{language.Japanese, "不明なオプションです: [%s]"},
+	}},
+	{Key: "No rooms match the conditions", List: []translationData{
+		{language.Japanese, "条件に合う会議室がありません"},
+	}},
+	{Key: "[Available rooms] %s - %s", List: []translationData{
+		{language.Japanese, "【空き会議室】 %s - %s"},
+	}},
+	{Key: "No rooms available", List: []translationData{
+		{language.Japanese, "空いている会議室はありません"},
+	}},
+	{Key: "Refreshed %d rooms", List: []translationData{
+		{language.Japanese, "%d 件の会議室を再取得しました"},
+	}},
+	{Key: "Floor %s", List: []translationData{
+		{language.Japanese, "%s階"},
+	}},
+	{Key: "%d people", List: []translationData{
+		{language.Japanese, "%d人"},
+	}},
+	{Key: "[%s]\t\t(Available)", List: []translationData{
+		{language.Japanese, "[%s]\t\t(空き)"},
+	}},
+	{Key: "[%s]\t\t(Available) free until %s", List: []translationData{
+		{language.Japanese, "[%s]\t\t(空き) %s まで"},
+	}},
+	{Key: "[%s]\t\t%s (busy until %s)", List: []translationData{
+		{language.Japanese, "[%s]\t\t%s (%s まで使用中)"},
+	}},
+
+	// Meeting Reminder
+	{Key: "Meeting reminders are turned off", List: []translationData{
+		{language.Japanese, "会議リマインダーを停止しました"},
+	}},
+	{Key: "Meeting reminders are off", List: []translationData{
+		{language.Japanese, "会議リマインダーは停止中です"},
+	}},
+	{Key: "Meeting reminders are on: %s before", List: []translationData{
+		{language.Japanese, "会議リマインダーは有効です: %s 前"},
+	}},
+	{Key: "You will get a DM %s before each meeting. Stop it by `remind off`", List: []translationData{
+		{language.Japanese, "会議の %s 前に DM でお知らせします。`remind off` で停止できます"},
+	}},
+	{Key: "[Reminder] [%s] starts at %s (in %d min)", List: []translationData{
+		{language.Japanese, "【リマインダー】 [%s] が %s から始まります (あと %d 分)"},
+	}},
+	{Key: "Location: %s", List: []translationData{
+		{language.Japanese, "場所: %s"},
+	}},
+	{Key: "Meet: %s", List: []translationData{
+		{language.Japanese, "Meet: %s"},
+	}},
+	{Key: "Attendees: %s", List: []translationData{
+		{language.Japanese, "参加者: %s"},
+	}},
+	{Key: "and %d more", List: []translationData{
+		{language.Japanese, "他 %d 名"},
+	}},
+	// Google Auth
+	{Key: "Authorization is not needed. This command is only for GOOGLE_AUTH_TYPE=user_oauth", List: []translationData{
+		{language.Japanese, "認証は不要です。このコマンドは GOOGLE_AUTH_TYPE=user_oauth の場合のみ使えます"},
+	}},
+	{Key: "Run `google:auth` in a direct message to the bot", List: []translationData{
+		{language.Japanese, "`google:auth` はボットへのダイレクトメッセージで実行してください"},
+	}},
+	{Key: "Open the URL with [%s] and run `google:auth <redirected URL>` with the URL of the redirected page: %s", List: []translationData{
+		{language.Japanese, "[%s] で URL を開き、リダイレクト先ページの URL で `google:auth <redirected URL>` を実行してください: %s"},
+	}},
+	{Key: "Set the URL of the redirected page, which has code and state", List: []translationData{
+		{language.Japanese, "code と state を含むリダイレクト先ページの URL を指定してください"},
+	}},
+	{Key: "Invalid or expired state. Run `google:auth` again.", List: []translationData{
+		{language.Japanese, "state が不正か期限切れです。もう一度 `google:auth` を実行してください"},
+	}},
+	{Key: "Authorized Google account [%s] does not match your email [%s]", List: []translationData{
+		{language.Japanese, "認証された Google アカウント [%s] があなたのメールアドレス [%s] と一致しません"},
+	}},
+	{Key: "Verified email address of the Google account is not found", List: []translationData{
+		{language.Japanese, "Google アカウントの確認済みメールアドレスが見つかりません"},
+	}},
+	{Key: "Authorized Google account [%s]", List: []translationData{
+		{language.Japanese, "Google アカウント [%s] を認証しました"},
+	}},
+	{Key: "Google account is unknown. Run it as a user.", List: []translationData{
+		{language.Japanese, "Google アカウントが不明です。ユーザーとして実行してください"},
+	}},
+	{Key: "Google account [%s] is not authorized yet. Run `google:auth` first.", List: []translationData{
+		{language.Japanese, "Google アカウント [%s] はまだ認証されていません。先に `google:auth` を実行してください"},
+	}},
+	// Calendar Add
+	{Key: "Room [%s] is not available at [%s - %s]", List: []translationData{
+		{language.Japanese, "[%s] は [%s - %s] に予約済みです"},
+	}},
+	{Key: "Conflicts with existing events of [%s]. Add --force to create anyway.", List: []translationData{
+		{language.Japanese, "[%s] の既存の予定と重なっています。それでも作成する場合は --force を付けてください"},
+	}},
+	{Key: "Created [%s] (%s - %s): %s", List: []translationData{
+		{language.Japanese, "[%s] (%s - %s) を作成しました: %s"},
+	}},
+	{Key: "Room [%s] is not found", List: []translationData{
+		{language.Japanese, "[%s] という会議室は見つかりません"},
+	}},
+	{Key: "Room [%s] matches multiple rooms: %s", List: []translationData{
+		{language.Japanese, "[%s] に該当する会議室が複数あります: %s"},
+	}},
+	{Key: "Set the title of the event", List: []translationData{
+		{language.Japanese, "予定のタイトルを指定してください"},
+	}},
+	{Key: "Set the start time of the event. e.g.) 15:00", List: []translationData{
+		{language.Japanese, "開始時刻を指定してください 例) 15:00"},
+	}},
+	{Key: "The start time has already passed: [%s]", List: []translationData{
+		{language.Japanese, "開始時刻が過ぎています: [%s]"},
+	}},
 
 	// Where
 	{Key: "Getting location of [%s] ...", List: []translationData{
@@ -98,6 +489,54 @@ var langs = []translation{
 	{Key: "all-day", List: []translationData{
 		{language.Japanese, "終日"},
 	}},
+	{Key: "Getting location of %d members ...", List: []translationData{
+		{language.Japanese, "%d 人の場所を確認中..."},
+	}},
+	{Key: "No members found", List: []translationData{
+		{language.Japanese, "メンバーが見つかりません"},
+	}},
+	{Key: "Name", List: []translationData{
+		{language.Japanese, "名前"},
+	}},
+	{Key: "Status", List: []translationData{
+		{language.Japanese, "状態"},
+	}},
+	{Key: "Location", List: []translationData{
+		{language.Japanese, "場所"},
+	}},
+	{Key: "Until", List: []translationData{
+		{language.Japanese, "まで"},
+	}},
+	{Key: "in a meeting", List: []translationData{
+		{language.Japanese, "会議中"},
+	}},
+	{Key: "OOO", List: []translationData{
+		{language.Japanese, "不在"},
+	}},
+	{Key: "free", List: []translationData{
+		{language.Japanese, "空き"},
+	}},
+	{Key: "unknown", List: []translationData{
+		{language.Japanese, "不明"},
+	}},
+	{Key: "in focus time", List: []translationData{
+		{language.Japanese, "集中モード"},
+	}},
+	{Key: "OOO until %s", List: []translationData{
+		{language.Japanese, "%s まで不在です"},
+	}},
+	{Key: "In focus time until %s", List: []translationData{
+		{language.Japanese, "%s まで集中モードです"},
+	}},
+	{Key: "Working from %s", List: []translationData{
+		{language.Japanese, "%s で勤務しています"},
+	}},
+	{Key: "home", List: []translationData{
+		{language.Japanese, "自宅"},
+	}},
+	{Key: "today", List: []translationData{
+		{language.Japanese, "今日"},
+	}},
 	{Key: "[AllDay] [%s - %s]", List: []translationData{
 		{language.Japanese, "【終日】[%s - %s]"},
 	}},
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed cron expression.
+// format: "<minute> <hour> <day of month> <month> <day of week>"
+type cronSchedule struct {
+	minute     map[int]struct{}
+	hour       map[int]struct{}
+	dayOfMonth map[int]struct{}
+	month      map[int]struct{}
+	dayOfWeek  map[int]struct{}
+
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+// parseCron parses cron expression.
+// Supported syntax in each field: "*", "*/n", "a", "a-b", "a-b/n" and comma separated list of them.
+func parseCron(spec string) (cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return cronSchedule{}, fmt.Errorf("cron spec must have 5 fields: [%s]", spec)
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return s, err
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return s, err
+	}
+	if s.dayOfMonth, err = parseCronField(fields[2], 1, 31); err != nil {
+		return s, err
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return s, err
+	}
+	if s.dayOfWeek, err = parseCronField(fields[4], 0, 7); err != nil {
+		return s, err
+	}
+	// both of 0 and 7 are Sunday.
+	if _, ok := s.dayOfWeek[7]; ok {
+		s.dayOfWeek[0] = struct{}{}
+	}
+	s.anyDayOfMonth = fields[2] == "*"
+	s.anyDayOfWeek = fields[4] == "*"
+	return s, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]struct{}, error) {
+	result := make(map[int]struct{})
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid cron step: [%s]", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		from, to := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			r := strings.SplitN(part, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(r[0]); err != nil {
+				return nil, fmt.Errorf("invalid cron range: [%s]", part)
+			}
+			if to, err = strconv.Atoi(r[1]); err != nil {
+				return nil, fmt.Errorf("invalid cron range: [%s]", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cron value: [%s]", part)
+			}
+			from, to = n, n
+		}
+
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("cron value is out of range: [%s]", part)
+		}
+		for i := from; i <= to; i += step {
+			result[i] = struct{}{}
+		}
+	}
+	return result, nil
+}
+
+// Match checks the time (truncated to minute) matches the schedule.
+func (s cronSchedule) Match(dt time.Time) bool {
+	if !hasValue(s.minute, dt.Minute()) ||
+		!hasValue(s.hour, dt.Hour()) ||
+		!hasValue(s.month, int(dt.Month())) {
+		return false
+	}
+
+	dom := hasValue(s.dayOfMonth, dt.Day())
+	dow := hasValue(s.dayOfWeek, int(dt.Weekday()))
+	switch {
+	case s.anyDayOfMonth && s.anyDayOfWeek:
+		return true
+	case s.anyDayOfMonth:
+		return dow
+	case s.anyDayOfWeek:
+		return dom
+	}
+	// same as standard cron, when both fields are restricted, either one matches.
+	return dom || dow
+}
+
+func hasValue(m map[int]struct{}, v int) bool {
+	_, ok := m[v]
+	return ok
+}
@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"sync"
+
+	"github.com/eure/bobo/engine"
+)
+
+// ReadyEngine wraps an engine to notify background tasks when bobo.Run has initialized it.
+// Pass the same ReadyEngine to bobo.Run and to background tasks.
+type ReadyEngine struct {
+	engine.Engine
+
+	once  sync.Once
+	ready chan struct{}
+}
+
+// NewReadyEngine returns initialized ReadyEngine.
+func NewReadyEngine(e engine.Engine) *ReadyEngine {
+	return &ReadyEngine{
+		Engine: e,
+		ready:  make(chan struct{}),
+	}
+}
+
+// Init initializes the engine and closes the ready channel at the first success.
+func (e *ReadyEngine) Init(conf engine.Config) error {
+	if err := e.Engine.Init(conf); err != nil {
+		return err
+	}
+	e.once.Do(func() {
+		close(e.ready)
+	})
+	return nil
+}
+
+// Ready returns a channel which is closed after the engine is initialized.
+func (e *ReadyEngine) Ready() <-chan struct{} {
+	return e.ready
+}
@@ -0,0 +1,256 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eure/bobo/command"
+	"github.com/eure/bobo/engine"
+	"github.com/eure/bobo/log"
+
+	"github.com/evalphobia/bobo-experiment/storage"
+)
+
+const defaultStateFile = "scheduler_state.json"
+
+// Scheduler runs registered commands on cron schedules and
+// posts the results to the configured channels.
+type Scheduler struct {
+	// Engine is used for posting results.
+	// Set the same engine which is used in bobo.Run.
+	// Jobs start after the engine is initialized.
+	Engine *ReadyEngine
+	// CommandSet is passed to commands of jobs.
+	// Set the same command set which is used in bobo.Run.
+	CommandSet *command.CommandSet
+	Logger     log.Logger
+	Jobs       []Job
+
+	// Timezone is default timezone for jobs. (e.g. "Asia/Tokyo")
+	Timezone string
+	// StateFile is a file path to save last run of jobs.
+	// It prevents double posting after restart.
+	StateFile string
+
+	store     *storage.JSONFile
+	stateMu   sync.Mutex
+	lastRuns  map[string]time.Time // key=Job.Name
+	closeOnce sync.Once
+	stopOnce  sync.Once
+	closeChan chan struct{}
+}
+
+// Job is a scheduled command.
+type Job struct {
+	Name    string
+	Spec    string // cron expression. (e.g. "0 9 * * 1-5")
+	Command command.CommandTemplate
+	Text    string // arguments for the command. (= TextOther)
+	Channel string // channel to post
+
+	Timezone     string
+	SkipWeekends bool
+	SkipHolidays bool
+	// Holidays is a static list of dates to skip. (e.g. "2006-01-02")
+	// It's not fetched from any calendar, so maintain it by hand every year.
+	Holidays []string
+
+	schedule cronSchedule
+	location *time.Location
+	holidays map[string]struct{}
+}
+
+// Run starts the scheduler loop and blocks until Stop is called.
+func (s *Scheduler) Run() error {
+	if s.Engine == nil {
+		return fmt.Errorf("[Scheduler] Engine is nil")
+	}
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	closeChan := s.getCloseChan()
+	select {
+	case <-closeChan:
+		return nil
+	case <-s.Engine.Ready():
+	}
+	s.logInfo("started: jobs=[%d]", len(s.Jobs))
+
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		select {
+		case <-closeChan:
+			return nil
+		case <-time.After(next.Sub(now)):
+			s.runJobs(next)
+		}
+	}
+}
+
+// Stop stops the scheduler loop.
+// It can be called before Run and more than once.
+func (s *Scheduler) Stop() {
+	closeChan := s.getCloseChan()
+	s.stopOnce.Do(func() {
+		close(closeChan)
+	})
+}
+
+func (s *Scheduler) getCloseChan() chan struct{} {
+	s.closeOnce.Do(func() {
+		s.closeChan = make(chan struct{})
+	})
+	return s.closeChan
+}
+
+func (s *Scheduler) init() error {
+	if s.Logger == nil {
+		s.Logger = log.DefaultLogger
+	}
+
+	defaultLoc, err := loadLocation(s.Timezone, time.Local)
+	if err != nil {
+		return err
+	}
+
+	jobs := make([]Job, 0, len(s.Jobs))
+	for _, j := range s.Jobs {
+		switch {
+		case j.Name == "":
+			return fmt.Errorf("[Scheduler] job name is empty: spec=[%s]", j.Spec)
+		case j.Command == nil:
+			return fmt.Errorf("[Scheduler] command is nil: job=[%s]", j.Name)
+		case j.Channel == "":
+			// channel is often set by envvar, so just skip it.
+			s.logInfo("skip job without channel: job=[%s]", j.Name)
+			continue
+		}
+
+		j.schedule, err = parseCron(j.Spec)
+		if err != nil {
+			return fmt.Errorf("[Scheduler] job=[%s] error=[%w]", j.Name, err)
+		}
+		j.location, err = loadLocation(j.Timezone, defaultLoc)
+		if err != nil {
+			return fmt.Errorf("[Scheduler] job=[%s] error=[%w]", j.Name, err)
+		}
+		j.holidays = make(map[string]struct{}, len(j.Holidays))
+		for _, h := range j.Holidays {
+			j.holidays[h] = struct{}{}
+		}
+		jobs = append(jobs, j)
+	}
+	s.Jobs = jobs
+
+	return s.loadState()
+}
+
+func (s *Scheduler) runJobs(dt time.Time) {
+	for _, j := range s.Jobs {
+		localTime := dt.In(j.location)
+		if !j.ShouldRun(localTime) {
+			continue
+		}
+		if s.hasRun(j.Name, dt) {
+			s.logInfo("skip already executed job: job=[%s] time=[%s]", j.Name, localTime.Format(time.RFC3339))
+			continue
+		}
+
+		// save state before running to avoid double posting when the command crashes the process.
+		if err := s.saveLastRun(j.Name, dt); err != nil {
+			s.logError("saveLastRun job=[%s] error=[%s]", j.Name, err.Error())
+		}
+		s.logInfo("run job: job=[%s] time=[%s]", j.Name, localTime.Format(time.RFC3339))
+		go j.Command.Exec(j.newCommandData(s.Engine, s.CommandSet))
+	}
+}
+
+// ShouldRun checks the job should be executed on the time.
+func (j Job) ShouldRun(localTime time.Time) bool {
+	if !j.schedule.Match(localTime) {
+		return false
+	}
+
+	switch localTime.Weekday() {
+	case time.Saturday, time.Sunday:
+		if j.SkipWeekends {
+			return false
+		}
+	}
+	if j.SkipHolidays {
+		if _, ok := j.holidays[localTime.Format("2006-01-02")]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// newCommandData creates command data as if someone mentions the command in the channel.
+func (j Job) newCommandData(e engine.Engine, cs *command.CommandSet) command.CommandData {
+	mention := j.Command.GetMentionCommand()
+	text := strings.TrimSpace(mention + " " + j.Text)
+	return command.CommandData{
+		Engine:      e,
+		RawText:     text,
+		Text:        text,
+		TextCommand: mention,
+		TextOther:   j.Text,
+		Channel:     j.Channel,
+		CommandSet:  cs,
+	}
+}
+
+func (s *Scheduler) getStateFile() string {
+	switch {
+	case s.StateFile != "":
+		return s.StateFile
+	case os.Getenv("BOBO_SCHEDULER_STATE_FILE") != "":
+		return os.Getenv("BOBO_SCHEDULER_STATE_FILE")
+	}
+	return defaultStateFile
+}
+
+func (s *Scheduler) loadState() error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	s.store = storage.NewJSONFile(s.getStateFile())
+	s.lastRuns = make(map[string]time.Time)
+	return s.store.Load(&s.lastRuns)
+}
+
+func (s *Scheduler) hasRun(name string, dt time.Time) bool {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	last, ok := s.lastRuns[name]
+	return ok && !last.Before(dt)
+}
+
+func (s *Scheduler) saveLastRun(name string, dt time.Time) error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	s.lastRuns[name] = dt
+	return s.store.Save(s.lastRuns)
+}
+
+func (s *Scheduler) logInfo(format string, v ...interface{}) {
+	s.Logger.Infof("Scheduler", format, v...)
+}
+
+func (s *Scheduler) logError(format string, v ...interface{}) {
+	s.Logger.Errorf("Scheduler", format, v...)
+}
+
+func loadLocation(name string, defaultLoc *time.Location) (*time.Location, error) {
+	if name == "" {
+		return defaultLoc, nil
+	}
+	return time.LoadLocation(name)
+}
@@ -0,0 +1,250 @@
+package slackapi
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/nlopes/slack"
+)
+
+// envvars of Slack token, which is the same order as bobo's slack engine.
+var tokenEnvNames = []string{
+	"SLACK_RTM_TOKEN",
+	"SLACK_BOT_TOKEN",
+	"SLACK_TOKEN",
+}
+
+var clientOnce sync.Once
+var slackCli *slack.Client
+
+// GetClient returns Slack API client.
+func GetClient() (*slack.Client, error) {
+	clientOnce.Do(func() {
+		for _, name := range tokenEnvNames {
+			if token := os.Getenv(name); token != "" {
+				slackCli = slack.New(token)
+				return
+			}
+		}
+	})
+	if slackCli == nil {
+		return nil, errors.New("Slack token is not set")
+	}
+	return slackCli, nil
+}
+
+// TargetType is a type of group mention.
+type TargetType int
+
+const (
+	TargetNone TargetType = iota
+	TargetChannel
+	TargetUserGroup
+)
+
+// ParseGroupTarget parses channel link or usergroup mention.
+// e.g.) "<#C0123|team-channel>", "<!subteam^S0123|@team>"
+func ParseGroupTarget(text string) (TargetType, string) {
+	text = strings.Trim(strings.TrimSpace(text), "<>")
+	if i := strings.Index(text, "|"); i >= 0 {
+		text = text[:i]
+	}
+
+	switch {
+	case strings.HasPrefix(text, "#C"), strings.HasPrefix(text, "#G"):
+		return TargetChannel, strings.TrimPrefix(text, "#")
+	case strings.HasPrefix(text, "!subteam^"):
+		return TargetUserGroup, strings.TrimPrefix(text, "!subteam^")
+	}
+	return TargetNone, ""
+}
+
+// GetMemberIDs returns user ids of the channel or usergroup.
+// It returns at most max users when max > 0.
+func GetMemberIDs(typ TargetType, id string, max int) ([]string, error) {
+	cli, err := GetClient()
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case TargetChannel:
+		return getChannelMemberIDs(cli, id, max)
+	case TargetUserGroup:
+		list, err := cli.GetUserGroupMembers(id)
+		if err != nil {
+			return nil, err
+		}
+		if max > 0 && len(list) > max {
+			list = list[:max]
+		}
+		return list, nil
+	}
+	return nil, errors.New("Unknown target type")
+}
+
+func getChannelMemberIDs(cli *slack.Client, channelID string, max int) ([]string, error) {
+	results := make([]string, 0, 64)
+	cursor := ""
+	for {
+		list, next, err := cli.GetUsersInConversation(&slack.GetUsersInConversationParameters{
+			ChannelID: channelID,
+			Cursor:    cursor,
+			Limit:     200,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, list...)
+		if max > 0 && len(results) >= max {
+			return results[:max], nil
+		}
+		if next == "" {
+			return results, nil
+		}
+		cursor = next
+	}
+}
+
+// GetUserTimezone returns timezone name of the user's profile. e.g.) "Asia/Tokyo"
+func GetUserTimezone(userID string) (string, error) {
+	cli, err := GetClient()
+	if err != nil {
+		return "", err
+	}
+
+	u, err := cli.GetUserInfo(userID)
+	if err != nil {
+		return "", err
+	}
+	return u.TZ, nil
+}
+
+// OpenDirectMessage opens a direct message with the user and returns the channel id.
+func OpenDirectMessage(userID string) (string, error) {
+	cli, err := GetClient()
+	if err != nil {
+		return "", err
+	}
+
+	ch, _, _, err := cli.OpenConversation(&slack.OpenConversationParameters{
+		Users: []string{userID},
+	})
+	if err != nil {
+		return "", err
+	}
+	return ch.ID, nil
+}
+
+// File is an attached file of a message.
+type File struct {
+	Name     string
+	Mimetype string
+	Size     int
+	URL      string // url_private_download
+}
+
+// IsImage checks the file is an image.
+func (f File) IsImage() bool {
+	return strings.HasPrefix(f.Mimetype, "image/")
+}
+
+// GetMessageFiles returns attached files of the message.
+// Replies in threads are not supported.
+func GetMessageFiles(channelID, timestamp string) ([]File, error) {
+	cli, err := GetClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := cli.GetConversationHistory(&slack.GetConversationHistoryParameters{
+		ChannelID: channelID,
+		Latest:    timestamp,
+		Inclusive: true,
+		Limit:     1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	for _, m := range resp.Messages {
+		if m.Timestamp != timestamp {
+			continue
+		}
+		for _, f := range m.Files {
+			files = append(files, File{
+				Name:     f.Name,
+				Mimetype: f.Mimetype,
+				Size:     f.Size,
+				URL:      f.URLPrivateDownload,
+			})
+		}
+	}
+	return files, nil
+}
+
+// ParseFileURL returns file id from Slack file URL.
+// e.g.) permalink "https://xxx.slack.com/files/U0123/F0123/image.png",
+// private url "https://files.slack.com/files-pri/T0123-F0123/image.png"
+func ParseFileURL(fileURL string) (string, bool) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return "", false
+	}
+	if host := u.Hostname(); host != "slack.com" && !strings.HasSuffix(host, ".slack.com") {
+		return "", false
+	}
+
+	paths := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(paths) >= 3 && paths[0] == "files":
+		return paths[2], strings.HasPrefix(paths[2], "F")
+	case len(paths) >= 2 && strings.HasPrefix(paths[0], "files-pri"):
+		i := strings.Index(paths[1], "-")
+		if i < 0 {
+			return "", false
+		}
+		id := paths[1][i+1:]
+		return id, strings.HasPrefix(id, "F")
+	}
+	return "", false
+}
+
+// GetFile returns the file of the id.
+func GetFile(fileID string) (File, error) {
+	cli, err := GetClient()
+	if err != nil {
+		return File{}, err
+	}
+
+	f, _, _, err := cli.GetFileInfo(fileID, 0, 0)
+	if err != nil {
+		return File{}, err
+	}
+	return File{
+		Name:     f.Name,
+		Mimetype: f.Mimetype,
+		Size:     f.Size,
+		URL:      f.URLPrivateDownload,
+	}, nil
+}
+
+// DownloadFile downloads the private file with the token.
+func DownloadFile(url string) ([]byte, error) {
+	cli, err := GetClient()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.Buffer{}
+	if err := cli.GetFile(url, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// JSONFile is a small local store which saves data as a json file.
+// It's used for persisting bot state across restarts.
+type JSONFile struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// NewJSONFile returns initialized *JSONFile.
+func NewJSONFile(path string) *JSONFile {
+	return &JSONFile{
+		Path: path,
+	}
+}
+
+// Load reads the json file into v.
+// It does nothing when the file does not exist.
+func (f *JSONFile) Load(v interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	byt, err := ioutil.ReadFile(f.Path)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	case len(byt) == 0:
+		return nil
+	}
+	return json.Unmarshal(byt, v)
+}
+
+// Save writes v into the json file.
+// To avoid a broken file, v is written into a temporary file and renamed to Path.
+func (f *JSONFile) Save(v interface{}) error {
+	byt, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dir := filepath.Dir(f.Path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, filepath.Base(f.Path)+".tmp.")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(byt); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}